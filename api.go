@@ -2,12 +2,15 @@ package gslb
 
 import (
 	"bytes"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/miekg/dns"
 	"gopkg.in/yaml.v3"
 )
 
@@ -116,19 +119,10 @@ func (g *GSLB) handleOverview() http.HandlerFunc {
 				atLeastOneBackendHealthy := false
 				var backends []map[string]interface{}
 				for _, be := range rec.Backends {
-					b := be.(*Backend)
-					b.mutex.RLock()
-					aliveStr := statusUnhealthy
-					if b.Alive && b.Enable {
-						aliveStr = statusHealthy
+					beMap, healthy := overviewBackend(be.(*Backend))
+					if healthy {
 						atLeastOneBackendHealthy = true
 					}
-					beMap := map[string]interface{}{
-						"address":          b.Address,
-						"alive":            aliveStr,
-						"last_healthcheck": b.LastHealthcheck.Format(time.RFC3339),
-					}
-					b.mutex.RUnlock()
 					backends = append(backends, beMap)
 				}
 				recMap := map[string]interface{}{
@@ -158,19 +152,10 @@ func (g *GSLB) handleOverview() http.HandlerFunc {
 				atLeastOneBackendHealthy := false
 				var backends []map[string]interface{}
 				for _, be := range rec.Backends {
-					b := be.(*Backend)
-					b.mutex.RLock()
-					aliveStr := statusUnhealthy
-					if b.Alive && b.Enable {
-						aliveStr = statusHealthy
+					beMap, healthy := overviewBackend(be.(*Backend))
+					if healthy {
 						atLeastOneBackendHealthy = true
 					}
-					beMap := map[string]interface{}{
-						"address":          b.Address,
-						"alive":            aliveStr,
-						"last_healthcheck": b.LastHealthcheck.Format(time.RFC3339),
-					}
-					b.mutex.RUnlock()
 					backends = append(backends, beMap)
 				}
 				recMap := map[string]interface{}{
@@ -193,6 +178,199 @@ func (g *GSLB) handleOverview() http.HandlerFunc {
 	}
 }
 
+// overviewBackend returns the overview representation of a backend and whether it is healthy.
+func overviewBackend(b *Backend) (map[string]interface{}, bool) {
+	details := b.GetCheckDetails()
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	healthy := b.Alive && b.Enable && b.dependencyError == ""
+	aliveStr := statusUnhealthy
+	if healthy {
+		aliveStr = statusHealthy
+	}
+	beMap := map[string]interface{}{
+		"address":          b.Address,
+		"alive":            aliveStr,
+		"degraded":         b.Degraded,
+		"stale":            b.Stale,
+		"last_healthcheck": b.LastHealthcheck.Format(time.RFC3339),
+	}
+	if b.Hostname != "" {
+		beMap["hostname"] = b.Hostname
+	}
+	if b.dependencyError != "" {
+		beMap["dependency_failure"] = b.dependencyError
+	}
+	if len(details) > 0 {
+		beMap["details"] = details
+	}
+	return beMap, healthy
+}
+
+// handleBackendHealthPush returns a handler receiving health reports for push healthchecks.
+func (g *GSLB) handleBackendHealthPush() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed. Only POST is supported."})
+			return
+		}
+		fqdn := dns.Fqdn(strings.ToLower(r.PathValue("record")))
+		address := r.PathValue("address")
+		checks, err := g.pushHealthChecks(fqdn, address)
+		if err != nil {
+			if !g.checkBasicAuth(w, r) {
+				return
+			}
+			status := http.StatusNotFound
+			if errors.Is(err, errPushNotConfigured) {
+				status = http.StatusConflict
+			}
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		// A push token of the backend replaces the basic auth credentials. When
+		// a token is set and no basic auth credentials are configured, the token
+		// is required.
+		authorized, tokenRequired := false, false
+		token, bearer := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		for _, push := range checks {
+			if push.Token == "" {
+				continue
+			}
+			tokenRequired = true
+			if bearer && subtle.ConstantTimeCompare([]byte(token), []byte(push.Token)) == 1 {
+				authorized = true
+			}
+		}
+		basicConfigured := g.APIBasicUser != "" && g.APIBasicPass != ""
+		if !authorized && (bearer || (tokenRequired && !basicConfigured)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
+			return
+		}
+		if !authorized && !g.checkBasicAuth(w, r) {
+			return
+		}
+
+		var req struct {
+			Status  string `json:"status"`
+			TTL     string `json:"ttl"`
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Invalid JSON"})
+			return
+		}
+		healthy, err := parsePushStatus(req.Status)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		var ttl time.Duration
+		if req.TTL != "" {
+			if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl <= 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ttl, expected a positive duration"})
+				return
+			}
+		}
+
+		expires, err := g.reportBackendHealth(fqdn, address, healthy, req.Message, ttl)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"record":  fqdn,
+			"address": address,
+			"status":  req.Status,
+			"expires": expires.Format(time.RFC3339),
+		})
+	}
+}
+
+// handleBackendChecks returns a handler listing the result history of each health check of a backend.
+func (g *GSLB) handleBackendChecks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !g.checkBasicAuth(w, r) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed. Only GET is supported."})
+			return
+		}
+		fqdn := dns.Fqdn(strings.ToLower(r.PathValue("fqdn")))
+		address := r.PathValue("address")
+
+		g.Mutex.RLock()
+		record, _ := g.findRecord(fqdn)
+		g.Mutex.RUnlock()
+		if record == nil {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Record not found"})
+			return
+		}
+		var backend *Backend
+		record.mutex.RLock()
+		for _, be := range record.Backends {
+			if b, ok := be.(*Backend); ok && b.Address == address {
+				backend = b
+			}
+		}
+		record.mutex.RUnlock()
+		if backend == nil {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Backend not found"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"record":  fqdn,
+			"address": address,
+			"checks":  backend.GetCheckHistory(),
+		})
+	}
+}
+
+// handleRunHealthChecks returns a handler running the health checks of the selected backends synchronously.
+func (g *GSLB) handleRunHealthChecks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !g.checkBasicAuth(w, r) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed. Only POST is supported."})
+			return
+		}
+		var filter healthcheckFilter
+		if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Invalid JSON"})
+			return
+		}
+		if filter.empty() {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "record, address, tags, or location required"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"results": g.runHealthChecksNow(filter),
+		})
+	}
+}
+
 // RegisterAPIHandlers registers all API endpoints to the provided mux.
 func (g *GSLB) RegisterAPIHandlers(mux *http.ServeMux) {
 	// Handler for /api/overview
@@ -203,6 +381,12 @@ func (g *GSLB) RegisterAPIHandlers(mux *http.ServeMux) {
 	mux.HandleFunc("/api/backends/disable", g.handleBulkSetBackendEnable(false))
 	// Handler for bulk enable (POST /api/backends/enable)
 	mux.HandleFunc("/api/backends/enable", g.handleBulkSetBackendEnable(true))
+	// Handler for push healthchecks (POST /api/backends/{record}/{address}/health)
+	mux.HandleFunc("/api/backends/{record}/{address}/health", g.handleBackendHealthPush())
+	// Handler for the health check history of a backend (GET /api/records/{fqdn}/backends/{address}/checks)
+	mux.HandleFunc("/api/records/{fqdn}/backends/{address}/checks", g.handleBackendChecks())
+	// Handler for on-demand health checks (POST /api/healthchecks/run)
+	mux.HandleFunc("/api/healthchecks/run", g.handleRunHealthChecks())
 }
 
 // bulkSetBackendEnable sets enable=true or false for all backends matching location or addressPrefix in the YAML config file.
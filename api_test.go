@@ -1,6 +1,7 @@
 package gslb
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -37,6 +38,7 @@ func TestAPIOverviewEndpoint(t *testing.T) {
 		Location:        "edge-eu",
 		LastHealthcheck: time.Date(2025, 7, 21, 13, 3, 29, 0, time.UTC),
 	}
+	backend.SetCheckDetail("exec", "OK - all good")
 	rec.Backends = []BackendInterface{backend}
 	g.Records["test."] = map[string]*Record{rec.Fqdn: rec}
 
@@ -66,6 +68,9 @@ func TestAPIOverviewEndpoint(t *testing.T) {
 	assert.Equal(t, "1.2.3.4", be["address"])
 	assert.Equal(t, "healthy", be["alive"])
 	assert.Equal(t, "2025-07-21T13:03:29Z", be["last_healthcheck"])
+	assert.Equal(t, false, be["degraded"])
+	assert.Equal(t, false, be["stale"])
+	assert.Equal(t, map[string]interface{}{"exec": "OK - all good"}, be["details"])
 }
 
 func TestAPIOverviewZoneEndpoint(t *testing.T) {
@@ -473,3 +478,176 @@ func (m *MockHealthCheckAPI) PerformCheck(backend *Backend, fqdn string, maxRetr
 }
 func (m *MockHealthCheckAPI) GetType() string                      { return "mock" }
 func (m *MockHealthCheckAPI) Equals(other GenericHealthCheck) bool { return true }
+
+func TestAPIBackendHealthPushEndpoint(t *testing.T) {
+	g, push := newPushTestGSLB()
+	g.APIBasicUser = "admin"
+	g.APIBasicPass = "secret"
+	backend := &Backend{Address: "10.0.0.1"}
+
+	mux := http.NewServeMux()
+	g.RegisterAPIHandlers(mux)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	post := func(path, body string, setAuth func(*http.Request)) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+path, strings.NewReader(body))
+		if setAuth != nil {
+			setAuth(req)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		return resp
+	}
+	bearer := func(token string) func(*http.Request) {
+		return func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+	}
+	basic := func(req *http.Request) { req.SetBasicAuth("admin", "secret") }
+
+	// Authentication with the push token
+	resp := post("/api/backends/worker.example.com/10.0.0.1/health", `{"status":"healthy","ttl":"30s","message":"idle"}`, bearer("agent-token"))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var body map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	resp.Body.Close()
+	assert.Equal(t, true, body["success"])
+	assert.Equal(t, "worker.example.com.", body["record"])
+	assert.True(t, push.PerformCheck(backend, "worker.example.com.", 0))
+
+	// Authentication with the API credentials
+	resp = post("/api/backends/worker.example.com./10.0.0.1/health", `{"status":"unhealthy"}`, basic)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+	assert.False(t, push.PerformCheck(backend, "worker.example.com.", 0))
+
+	resp = post("/api/backends/worker.example.com/10.0.0.1/health", `{"status":"healthy"}`, bearer("wrong"))
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+	resp = post("/api/backends/worker.example.com/10.0.0.1/health", `{"status":"healthy"}`, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+	resp = post("/api/backends/unknown.example.com/10.0.0.1/health", `{"status":"healthy"}`, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+	assert.False(t, push.PerformCheck(backend, "worker.example.com.", 0))
+
+	resp = post("/api/backends/unknown.example.com/10.0.0.1/health", `{"status":"healthy"}`, basic)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+	resp = post("/api/backends/worker.example.com/10.0.0.2/health", `{"status":"healthy"}`, basic)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp.Body.Close()
+	resp = post("/api/backends/worker.example.com/10.0.0.1/health", `{"status":"up"}`, basic)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+	resp = post("/api/backends/worker.example.com/10.0.0.1/health", `{"status":"healthy","ttl":"-1s"}`, basic)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	getResp, err := http.Get(ts.URL + "/api/backends/worker.example.com/10.0.0.1/health")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, getResp.StatusCode)
+	getResp.Body.Close()
+}
+
+func TestAPIBackendHealthPushEndpoint_TokenWithoutBasicAuth(t *testing.T) {
+	g, push := newPushTestGSLB()
+	backend := &Backend{Address: "10.0.0.1"}
+
+	mux := http.NewServeMux()
+	g.RegisterAPIHandlers(mux)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	// Without basic auth credentials, the push token is required
+	resp, err := http.Post(ts.URL+"/api/backends/worker.example.com/10.0.0.1/health", "application/json", strings.NewReader(`{"status":"healthy"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+	assert.False(t, push.PerformCheck(backend, "worker.example.com.", 0))
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/backends/worker.example.com/10.0.0.1/health", strings.NewReader(`{"status":"healthy"}`))
+	req.Header.Set("Authorization", "Bearer agent-token")
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+	assert.True(t, push.PerformCheck(backend, "worker.example.com.", 0))
+}
+
+func TestAPIBackendChecksEndpoint(t *testing.T) {
+	RegisterMetrics()
+	g, _ := newPushTestGSLB()
+	backend := g.Records["example.com."]["worker.example.com."].Backends[0].(*Backend)
+	backend.runHealthChecks(scrapeSettings{timeout: time.Second})
+
+	mux := http.NewServeMux()
+	g.RegisterAPIHandlers(mux)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/api/records/worker.example.com/backends/10.0.0.1/checks")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var body struct {
+		Record  string         `json:"record"`
+		Address string         `json:"address"`
+		Checks  []CheckHistory `json:"checks"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	resp.Body.Close()
+	assert.Equal(t, "worker.example.com.", body.Record)
+	assert.Equal(t, "10.0.0.1", body.Address)
+	assert.Len(t, body.Checks, 1)
+	assert.Equal(t, "push", body.Checks[0].Type)
+	assert.Len(t, body.Checks[0].Results, 1)
+	assert.Equal(t, "other", body.Checks[0].Results[0].Reason)
+	assert.Equal(t, "no report received", body.Checks[0].Results[0].Error)
+
+	resp, err = http.Get(ts.URL + "/api/records/worker.example.com/backends/10.0.0.9/checks")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+	resp, err = http.Get(ts.URL + "/api/records/unknown.example.com/backends/10.0.0.1/checks")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestAPIRunHealthChecksEndpoint(t *testing.T) {
+	RegisterMetrics()
+	g, _ := newPushTestGSLB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	g.startScheduler(ctx)
+
+	mux := http.NewServeMux()
+	g.RegisterAPIHandlers(mux)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+"/api/healthchecks/run", "application/json", strings.NewReader(`{"record":"worker.example.com.","address":"10.0.0.2"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var body struct {
+		Success bool                   `json:"success"`
+		Results []healthcheckRunResult `json:"results"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	resp.Body.Close()
+	assert.True(t, body.Success)
+	assert.Len(t, body.Results, 1)
+	assert.Equal(t, "10.0.0.2", body.Results[0].Address)
+	assert.True(t, body.Results[0].Alive)
+	assert.Equal(t, "mock", body.Results[0].Checks[0].Type)
+
+	resp, err = http.Post(ts.URL+"/api/healthchecks/run", "application/json", strings.NewReader(`{}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	getResp, err := http.Get(ts.URL + "/api/healthchecks/run")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, getResp.StatusCode)
+	getResp.Body.Close()
+}
@@ -1,8 +1,8 @@
 package gslb
 
 import (
-	"context"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
@@ -13,7 +13,8 @@ import (
 type Backend struct {
 	Fqdn            string               // Fully qualified domain name
 	Description     string               // Description of the backend
-	Address         string               // IP address or hostname
+	Address         string               // IP address
+	Hostname        string               // Hostname the address was resolved from, empty for configured addresses
 	Priority        int                  // Priority for load balancing
 	Weight          int                  // Weight for weighted load balancing
 	Enable          bool                 // Enable or disable the backend
@@ -26,6 +27,24 @@ type Backend struct {
 	ASN             string               // ASN for GeoIP
 	Location        string               // location
 	LastHealthcheck time.Time            // Last time a healthcheck was launched
+	Degraded        bool                 // Indicates if a health check reported a warning on the last run
+	Stale           bool                 // Indicates that the health was restored from the state file and not checked yet
+	ScrapeInterval  string               // Interval between health checks, overrides the record one if set
+	ScrapeTimeout   string               // Maximum duration of each health check, overrides the record one if set
+	ScrapeRetries   *int                 // Retries of each health check, overrides the record one if set
+	DependsOn       []Dependency         // Records which must have a healthy backend for this backend to be healthy
+	checkOverrides  []scrapeOverrides    // Scrape settings of each health check, indexed like HealthChecks
+	checkStates     []checkState         // Scheduling state of each health check, indexed like HealthChecks
+	nextRun         time.Time            // Next time a health check is due
+	fastUntil       time.Time            // End of the fast probing window after a state change
+	checked         bool                 // Set after the first health check run
+	checkDetails    map[string]string    // Last output reported by health checks, keyed by check type
+	warningReported bool                 // Set by health checks reporting a warning, read on probe copies only
+	failureReason   string               // Set by a health check reporting a failure, read on probe copies only
+	failureError    string               // Error message of the reported failure
+	certExpiry      time.Time            // Set by a health check reporting a certificate expiry, read on probe copies only
+	history         []checkHistory       // Result history of each health check, indexed like HealthChecks
+	dependencyError string               // First unmet dependency, the backend is unhealthy while set
 	mutex           sync.RWMutex
 }
 
@@ -98,18 +117,22 @@ func (b *Backend) GetLocation() string {
 
 func (b *Backend) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var raw struct {
-		Description  string        `yaml:"description" default:""`
-		Address      string        `yaml:"address" default:"127.0.0.1"`
-		Priority     int           `yaml:"priority" default:"0"`
-		Weight       int           `yaml:"weight" default:"1"`
-		Enable       bool          `yaml:"enable" default:"true"`
-		Tags         []string      `yaml:"tags"`
-		Timeout      string        `yaml:"timeout" default:"5s"`
-		HealthChecks []HealthCheck `yaml:"healthchecks"`
-		Country      string        `yaml:"country"`
-		City         string        `yaml:"city"`
-		ASN          string        `yaml:"asn"`
-		Location     string        `yaml:"location"`
+		Description    string        `yaml:"description" default:""`
+		Address        string        `yaml:"address" default:"127.0.0.1"`
+		Priority       int           `yaml:"priority" default:"0"`
+		Weight         int           `yaml:"weight" default:"1"`
+		Enable         bool          `yaml:"enable" default:"true"`
+		Tags           []string      `yaml:"tags"`
+		Timeout        string        `yaml:"timeout" default:"5s"`
+		HealthChecks   []HealthCheck `yaml:"healthchecks"`
+		Country        string        `yaml:"country"`
+		City           string        `yaml:"city"`
+		ASN            string        `yaml:"asn"`
+		Location       string        `yaml:"location"`
+		ScrapeInterval string        `yaml:"scrape_interval"`
+		ScrapeTimeout  string        `yaml:"scrape_timeout"`
+		ScrapeRetries  *int          `yaml:"scrape_retries"`
+		DependsOn      []Dependency  `yaml:"depends_on"`
 	}
 	defaults.Set(&raw)
 	if err := unmarshal(&raw); err != nil {
@@ -126,16 +149,38 @@ func (b *Backend) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	b.City = raw.City
 	b.ASN = raw.ASN
 	b.Location = raw.Location
+	b.ScrapeInterval = raw.ScrapeInterval
+	b.ScrapeTimeout = raw.ScrapeTimeout
+	b.ScrapeRetries = raw.ScrapeRetries
+	b.DependsOn = raw.DependsOn
+	if err := b.scrapeOverrides().validate(); err != nil {
+		return fmt.Errorf("backend %s: %w", b.Address, err)
+	}
 	for _, hc := range raw.HealthChecks {
 		specificHC, err := hc.ToSpecificHealthCheck()
 		if err != nil {
 			return fmt.Errorf("error converting healthcheck for backend %s: %w", b.Address, err)
 		}
+		overrides := hc.scrapeOverrides()
+		if err := overrides.validate(); err != nil {
+			return fmt.Errorf("healthcheck %s of backend %s: %w", specificHC.GetType(), b.Address, err)
+		}
 		b.HealthChecks = append(b.HealthChecks, specificHC)
+		b.checkOverrides = append(b.checkOverrides, overrides)
 	}
 	return nil
 }
 
+// applySourceBinding resolves the global source binding into the health checks
+// of the backend. It is called on backends which are loaded and not used yet.
+func (b *Backend) applySourceBinding(global SourceBinding) {
+	for _, hc := range b.HealthChecks {
+		if check, ok := hc.(sourceBoundCheck); ok {
+			check.inheritSource(global)
+		}
+	}
+}
+
 // removeBackend stops the health check and performs cleanup for the backend
 func (b *Backend) removeBackend() {
 	b.mutex.Lock()
@@ -162,74 +207,289 @@ func (b *Backend) updateBackend(newBackend BackendInterface) {
 	if !healthChecksEqual(b.HealthChecks, newBackend.GetHealthChecks()) {
 		log.Debugf("[%s] backend %s health checks have changed.", b.Fqdn, b.Address)
 		b.HealthChecks = newBackend.GetHealthChecks()
+		b.history = nil
+		b.checkStates = nil
+		b.nextRun = time.Time{}
+	}
+
+	// Check if the scrape settings have changed
+	if other, ok := newBackend.(*Backend); ok {
+		if !b.scrapeOverrides().equals(other.scrapeOverrides()) || !slices.EqualFunc(b.checkOverrides, other.checkOverrides, scrapeOverrides.equals) {
+			log.Debugf("[%s] backend %s scrape settings have changed.", b.Fqdn, b.Address)
+			b.ScrapeInterval, b.ScrapeTimeout, b.ScrapeRetries = other.ScrapeInterval, other.ScrapeTimeout, other.ScrapeRetries
+			b.checkOverrides = other.checkOverrides
+			b.nextRun = time.Time{}
+			for i := range b.checkStates {
+				b.checkStates[i].next = time.Time{}
+			}
+		}
+		if !dependenciesEqual(b.DependsOn, other.DependsOn) {
+			log.Debugf("[%s] backend %s dependencies have changed.", b.Fqdn, b.Address)
+			b.DependsOn = other.DependsOn
+		}
 	}
 }
 
-func (b *Backend) runHealthChecks(maxRetries int, scrapeTimeout time.Duration) {
+// SetCheckDetail records the last diagnostic output of a health check, shown by the overview API.
+func (b *Backend) SetCheckDetail(checkType, detail string) {
 	b.mutex.Lock()
-	b.LastHealthcheck = time.Now()
+	defer b.mutex.Unlock()
+	if b.checkDetails == nil {
+		b.checkDetails = make(map[string]string)
+	}
+	b.checkDetails[checkType] = redactSecrets(detail)
+}
+
+// GetCheckDetails returns a copy of the last diagnostic output of each health check.
+func (b *Backend) GetCheckDetails() map[string]string {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	details := make(map[string]string, len(b.checkDetails))
+	for checkType, detail := range b.checkDetails {
+		details[checkType] = detail
+	}
+	return details
+}
+
+// ReportWarning marks the backend as degraded for the current health check run.
+func (b *Backend) ReportWarning() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.warningReported = true
+}
+
+// ReportCertificateExpiry sets the certificate expiry metric of the backend, the
+// expiry is replayed on the records sharing the health check.
+func (b *Backend) ReportCertificateExpiry(fqdn, typeStr string, notAfter time.Time) {
+	SetBackendCertificateExpiry(fqdn, b.Address, typeStr, float64(notAfter.Unix()))
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.certExpiry = notAfter
+}
+
+// runHealthChecks runs the health checks of the backend which are due, or all
+// of them when forced, and updates its health from the last result of each check.
+// It returns the result of each health check run.
+func (b *Backend) runHealthChecks(settings scrapeSettings) []CheckHistory {
+	start := time.Now()
+
+	// Plan the health checks to run with their effective settings
+	type checkPlan struct {
+		index int
+		check GenericHealthCheck
+		checkSettings
+	}
+	var plans []checkPlan
+	b.mutex.Lock()
+	b.LastHealthcheck = start
+	checks := b.HealthChecks
+	if len(b.checkStates) != len(checks) {
+		b.checkStates = make([]checkState, len(checks))
+	}
+	backendOverrides := b.scrapeOverrides()
+	for i, hc := range checks {
+		var checkOverrides scrapeOverrides
+		if i < len(b.checkOverrides) {
+			checkOverrides = b.checkOverrides[i]
+		}
+		plan := checkPlan{index: i, check: hc, checkSettings: settings.forCheck(backendOverrides, checkOverrides)}
+		if _, push := hc.(*PushHealthCheck); settings.pushOnly {
+			if push {
+				plans = append(plans, plan)
+			}
+		} else if settings.force || !b.checkStates[i].next.After(start) {
+			plans = append(plans, plan)
+		}
+	}
 	b.mutex.Unlock()
-	var wg sync.WaitGroup
-	results := make([]bool, len(b.HealthChecks))
 
 	log.Debugf("[%s] starting health check for backend: %s", b.Fqdn, b.Address)
 
 	// Gather the list of health check types
 	var healthChecksList []string
-	for _, healthCheck := range b.HealthChecks {
-		healthChecksList = append(healthChecksList, healthCheck.GetType())
+	for _, plan := range plans {
+		healthChecksList = append(healthChecksList, plan.check.GetType())
 	}
 
-	// Iterate over all health checks
-	for i, hc := range b.HealthChecks {
-		wg.Add(1) // Increment WaitGroup counter for each health check
-		go func(i int, hc GenericHealthCheck) {
-			defer wg.Done() // Decrement WaitGroup counter when the goroutine finishes
-
-			// Create a context with timeout for the health check
-			ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
-			defer cancel()
-
-			resultChan := make(chan bool, 1)
-
-			// Goroutine to perform the health check
-			go func() {
-				resultChan <- hc.PerformCheck(b, b.Fqdn, maxRetries)
-			}()
-
-			// Wait for either the result or a timeout
-			select {
-			case results[i] = <-resultChan:
-			case <-ctx.Done():
-				log.Debugf("[%s] health check timed out for backend: %s, check: %s", b.Fqdn, b.Address, hc.GetType())
-				results[i] = false
+	// Run the health checks concurrently. A check still running after its
+	// timeout is considered failed, but the run only returns once the check is
+	// done, so that hung probes keep holding their worker and destination slot.
+	type checkResult struct {
+		plan   checkPlan
+		result sharedResult
+	}
+	resultChan := make(chan checkResult, len(plans))
+	for _, plan := range plans {
+		go func(plan checkPlan) {
+			// A forced run does not reuse the results of the shared probes
+			freshness := plan.interval
+			if settings.force {
+				freshness = 0
+			}
+			resultChan <- checkResult{plan: plan, result: b.performSharedCheck(plan.check, plan.retries, plan.timeout, freshness)}
+		}(plan)
+	}
+	running := len(plans)
+	defer func() {
+		for ; running > 0; running-- {
+			<-resultChan
+		}
+	}()
+
+	results := make([]checkResult, 0, len(plans))
+	resolved := make(map[int]CheckResult, len(plans))
+	record := func(check checkResult) {
+		result := CheckResult{
+			Time:       check.result.started,
+			DurationMs: float64(check.result.duration.Microseconds()) / 1000,
+			Success:    check.result.alive,
+			Reason:     check.result.reason,
+			Error:      check.result.err,
+		}
+		resolved[check.plan.index] = result
+		results = append(results, check)
+		b.recordCheckResult(check.plan.index, result)
+	}
+	for len(results) < len(plans) {
+		var deadline time.Time
+		for _, plan := range plans {
+			if _, done := resolved[plan.index]; !done && (deadline.IsZero() || start.Add(plan.timeout).Before(deadline)) {
+				deadline = start.Add(plan.timeout)
+			}
+		}
+		timer := time.NewTimer(time.Until(deadline))
+		select {
+		case check := <-resultChan:
+			running--
+			if _, done := resolved[check.plan.index]; !done {
+				record(check)
+			}
+		case <-timer.C:
+			for _, plan := range plans {
+				if _, done := resolved[plan.index]; done || time.Now().Before(start.Add(plan.timeout)) {
+					continue
+				}
+				log.Debugf("[%s] health check timed out for backend: %s, check: %s", b.Fqdn, b.Address, plan.check.GetType())
+				record(checkResult{plan: plan, result: sharedResult{
+					started:  start,
+					duration: plan.timeout,
+					reason:   "timeout",
+					err:      "health check still running after the scrape timeout",
+				}})
 			}
-		}(i, hc)
+		}
+		timer.Stop()
+	}
+	history := make([]CheckHistory, 0, len(plans))
+	for _, plan := range plans {
+		history = append(history, CheckHistory{Type: plan.check.GetType(), Results: []CheckResult{resolved[plan.index]}})
 	}
 
-	// Wait for all health check goroutines to complete before returning the results.
-	wg.Wait()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if len(b.checkStates) != len(checks) {
+		// The health checks were replaced by a reload during the run
+		b.checkStates = make([]checkState, len(b.HealthChecks))
+		b.nextRun = time.Time{}
+		return history
+	}
+	for _, check := range results {
+		state := &b.checkStates[check.plan.index]
+		state.alive = check.result.alive
+		state.warning = check.result.warning
+		if check.result.alive {
+			state.failures = 0
+		} else {
+			state.failures++
+		}
+	}
 
-	// Update the backend's Alive status
-	alive := true
-	for _, result := range results {
-		if !result {
-			alive = false
-			break
+	// Update the backend's Alive status from the last result of every check
+	alive, degraded := true, false
+	for _, state := range b.checkStates {
+		alive = alive && state.alive
+		degraded = degraded || state.warning
+	}
+	if b.checked && alive != b.Alive && settings.fastInterval > 0 && settings.fastWindow > 0 {
+		log.Debugf("[%s] backend %s changed state, probing every %s for %s", b.Fqdn, b.Address, settings.fastInterval, settings.fastWindow)
+		b.fastUntil = start.Add(settings.fastWindow)
+	}
+	fast := settings.fastInterval > 0 && start.Before(b.fastUntil)
+
+	// Schedule the next run of each check
+	interval := settings.forCheck(backendOverrides, scrapeOverrides{}).interval
+	b.nextRun = start.Add(interval)
+	for _, check := range results {
+		state := &b.checkStates[check.plan.index]
+		state.next = start.Add(settings.nextInterval(check.plan.interval, state.failures, fast))
+	}
+	for i := range b.checkStates {
+		state := &b.checkStates[i]
+		if fast && state.next.After(start.Add(settings.fastInterval)) {
+			state.next = start.Add(settings.fastInterval)
+		}
+		if i == 0 || state.next.Before(b.nextRun) {
+			b.nextRun = state.next
 		}
 	}
-	b.mutex.Lock()
+
 	b.Alive = alive
-	b.mutex.Unlock()
+	b.Degraded = alive && degraded
+	b.Stale = false
+	b.checked = true
 
 	log.Debugf("[%s] backend status [address=%s]: healthchecks=%s alive=%v", b.Fqdn, b.Address, healthChecksList, b.Alive)
+	return history
+}
+
+// scrapeOverrides returns the scrape settings of the backend. The caller must hold the mutex.
+func (b *Backend) scrapeOverrides() scrapeOverrides {
+	return scrapeOverrides{Interval: b.ScrapeInterval, Timeout: b.ScrapeTimeout, Retries: b.ScrapeRetries}
+}
+
+// isDue reports whether a health check of the backend is due.
+func (b *Backend) isDue(now time.Time) bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return !b.nextRun.After(now)
+}
+
+// getNextRun returns the time of the next due health check, zero before the first run.
+func (b *Backend) getNextRun() time.Time {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.nextRun
 }
 
 func (b *Backend) IsHealthy() bool {
 	b.mutex.RLock()
 	defer b.mutex.RUnlock()
 
-	return b.Alive && b.Enable
+	return b.Alive && b.Enable && b.dependencyError == ""
+}
+
+// setDependencyFailure sets the first unmet dependency of the backend, empty
+// when all are met, and reports whether it changed.
+func (b *Backend) setDependencyFailure(dependency string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.dependencyError == dependency {
+		return false
+	}
+	if dependency == "" {
+		log.Infof("[%s] backend %s dependencies are met again", b.Fqdn, b.Address)
+	} else {
+		log.Infof("[%s] backend %s unhealthy, dependency %s has no healthy backend", b.Fqdn, b.Address, dependency)
+	}
+	b.dependencyError = dependency
+	return true
+}
+
+// GetDependencyFailure returns the first unmet dependency of the backend, empty when all are met.
+func (b *Backend) GetDependencyFailure() string {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.dependencyError
 }
 
 type BackendInterface interface {
@@ -248,7 +508,7 @@ type BackendInterface interface {
 	GetASN() string
 	GetLocation() string
 	IsHealthy() bool
-	runHealthChecks(retries int, timeout time.Duration)
+	runHealthChecks(settings scrapeSettings) []CheckHistory
 	removeBackend()
 	updateBackend(newBackend BackendInterface)
 	Lock()
@@ -0,0 +1,307 @@
+package gslb
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"gopkg.in/yaml.v3"
+)
+
+// hostnameResolveTimeout bounds the resolution of a backend hostname.
+const hostnameResolveTimeout = 5 * time.Second
+
+// hostnamePattern matches the hostnames accepted as backend address.
+var hostnamePattern = regexp.MustCompile(`^(?i)[a-z0-9_]([a-z0-9_-]*[a-z0-9])?(\.[a-z0-9_]([a-z0-9_-]*[a-z0-9])?)*\.?$`)
+
+// isHostname reports whether a backend address is a valid hostname.
+func isHostname(address string) bool {
+	_, ok := dns.IsDomainName(address)
+	return ok && hostnamePattern.MatchString(address)
+}
+
+// hostnameBackend is a backend configured with a hostname instead of an address.
+// The hostname is resolved periodically and expanded to one backend per resolved
+// address, each one checked and selected individually.
+type hostnameBackend struct {
+	Hostname      string
+	definition    []byte        // YAML definition of the backend, decoded for each resolved address
+	source        SourceBinding // Global source binding of the probes, resolved into the checks of each backend
+	addresses     []string      // Last resolved addresses, sorted
+	nextResolve   time.Time     // Next resolution, zero when never resolved
+	resolving     bool
+	saved         map[string]backendState // State file entries of the record, restored on the first resolution
+	restoreEnable bool                    // Whether the saved enable flags are restored as well
+}
+
+// newBackend returns the backend of one resolved address.
+func (h *hostnameBackend) newBackend(address string) (*Backend, error) {
+	var backend Backend
+	if err := yaml.Unmarshal(h.definition, &backend); err != nil {
+		return nil, fmt.Errorf("failed to decode backend %s: %w", h.Hostname, err)
+	}
+	backend.Address = address
+	backend.Hostname = h.Hostname
+	backend.applySourceBinding(h.source)
+	return &backend, nil
+}
+
+// resolvedFrom reports whether the backend was resolved from the hostname.
+func resolvedFrom(backend BackendInterface, hostname string) bool {
+	b, ok := backend.(*Backend)
+	return ok && b.Hostname != "" && b.Hostname == hostname
+}
+
+// isResolved reports whether the backend was resolved from a hostname.
+func isResolved(backend BackendInterface) bool {
+	b, ok := backend.(*Backend)
+	return ok && b.Hostname != ""
+}
+
+// applyHostnameAddresses updates the backends resolved from a hostname: backends are
+// added for new addresses and removed for the addresses which disappeared. An address
+// already used by another backend of the record is skipped. It reports whether the
+// backends changed, the new backends are checked by the scheduler. The caller must
+// hold the mutex.
+func (r *Record) applyHostnameAddresses(h *hostnameBackend, addresses []string) bool {
+	if !slices.Contains(r.hostnames, h) {
+		// The hostname was removed by a reload meanwhile
+		return false
+	}
+	slices.Sort(addresses)
+	if slices.Equal(h.addresses, addresses) {
+		return false
+	}
+	log.Infof("[%s] backend hostname %s resolved to %v", r.Fqdn, h.Hostname, addresses)
+	h.addresses = addresses
+
+	for i := 0; i < len(r.Backends); {
+		backend := r.Backends[i]
+		if resolvedFrom(backend, h.Hostname) && !slices.Contains(addresses, backend.GetAddress()) {
+			log.Debugf("[%s] backend %s removed, no longer resolved from %s", r.Fqdn, backend.GetAddress(), h.Hostname)
+			backend.removeBackend()
+			r.Backends = append(r.Backends[:i], r.Backends[i+1:]...)
+			continue
+		}
+		i++
+	}
+
+	for _, address := range addresses {
+		if slices.ContainsFunc(r.Backends, func(b BackendInterface) bool { return b.GetAddress() == address }) {
+			continue
+		}
+		backend, err := h.newBackend(address)
+		if err != nil {
+			log.Errorf("[%s] %v", r.Fqdn, err)
+			continue
+		}
+		log.Debugf("[%s] new backend %s resolved from %s", r.Fqdn, address, h.Hostname)
+		backend.SetFqdn(r.Fqdn)
+		if saved, found := h.saved[address]; found {
+			log.Debugf("[%s] restored the saved state of backend %s", r.Fqdn, address)
+			backend.restoreState(saved, h.restoreEnable)
+		}
+		r.Backends = append(r.Backends, backend)
+	}
+	h.saved = nil
+	r.updateRecordHealthStatus()
+	return true
+}
+
+// updateHostnames replaces the hostname backends after a reload. The backends
+// resolved from a kept hostname are updated with its new definition, those of
+// removed hostnames are deleted. The caller must hold the mutex.
+func (r *Record) updateHostnames(newHostnames []*hostnameBackend) {
+	hostnames := make([]*hostnameBackend, 0, len(newHostnames))
+	for _, newHostname := range newHostnames {
+		i := slices.IndexFunc(r.hostnames, func(h *hostnameBackend) bool { return h.Hostname == newHostname.Hostname })
+		if i < 0 {
+			log.Debugf("[%s] new backend hostname added %s", r.Fqdn, newHostname.Hostname)
+			hostnames = append(hostnames, newHostname)
+			continue
+		}
+		// Keep the resolution state, update the resolved backends
+		h := r.hostnames[i]
+		h.definition = newHostname.definition
+		h.source = newHostname.source
+		for _, backend := range r.Backends {
+			if !resolvedFrom(backend, h.Hostname) {
+				continue
+			}
+			updated, err := h.newBackend(backend.GetAddress())
+			if err != nil {
+				log.Errorf("[%s] %v", r.Fqdn, err)
+				continue
+			}
+			updated.SetFqdn(r.Fqdn)
+			backend.updateBackend(updated)
+		}
+		hostnames = append(hostnames, h)
+	}
+
+	for i := 0; i < len(r.Backends); {
+		backend := r.Backends[i]
+		if isResolved(backend) && !slices.ContainsFunc(hostnames, func(h *hostnameBackend) bool { return resolvedFrom(backend, h.Hostname) }) {
+			log.Debugf("[%s] backend %s removed with its hostname", r.Fqdn, backend.GetAddress())
+			backend.removeBackend()
+			r.Backends = append(r.Backends[:i], r.Backends[i+1:]...)
+			continue
+		}
+		i++
+	}
+	r.hostnames = hostnames
+}
+
+// runHostnameResolver resolves the backend hostnames of all records when they
+// are due, until the context is done.
+func (g *GSLB) runHostnameResolver(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		g.refreshHostnames(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshHostnames starts the resolution of the backend hostnames which are due.
+func (g *GSLB) refreshHostnames(ctx context.Context, now time.Time) {
+	g.Mutex.RLock()
+	defer g.Mutex.RUnlock()
+	for _, records := range g.Records {
+		for _, record := range records {
+			record.mutex.Lock()
+			for _, h := range record.hostnames {
+				if !h.resolving && !now.Before(h.nextResolve) {
+					h.resolving = true
+					go g.resolveHostnameBackend(ctx, record, h)
+				}
+			}
+			record.mutex.Unlock()
+		}
+	}
+}
+
+// resolveHostnameBackend resolves a backend hostname and updates the backends of
+// the record. The previous addresses are kept when the resolution fails.
+func (g *GSLB) resolveHostnameBackend(ctx context.Context, record *Record, h *hostnameBackend) {
+	resolveCtx, cancel := context.WithTimeout(ctx, hostnameResolveTimeout)
+	addresses, ttl, err := g.resolveHostname(resolveCtx, h.Hostname)
+	cancel()
+
+	record.mutex.Lock()
+	h.resolving = false
+	changed := false
+	if err != nil {
+		log.Warningf("[%s] failed to resolve backend hostname %s, keeping %v: %v", record.Fqdn, h.Hostname, h.addresses, err)
+		h.nextResolve = time.Now().Add(g.GetHostnameRefreshMin())
+	} else {
+		h.nextResolve = time.Now().Add(g.hostnameRefresh(ttl))
+		changed = record.applyHostnameAddresses(h, addresses)
+	}
+	record.mutex.Unlock()
+
+	if changed {
+		if g.scheduler != nil {
+			g.scheduler.wake(record)
+		}
+		g.Mutex.RLock()
+		g.updateMetrics()
+		g.Mutex.RUnlock()
+	}
+}
+
+// hostnameRefresh returns the delay until the next resolution of a hostname,
+// its TTL bounded by the minimum and maximum refresh intervals.
+func (g *GSLB) hostnameRefresh(ttl time.Duration) time.Duration {
+	return min(max(ttl, g.GetHostnameRefreshMin()), g.GetHostnameRefreshMax())
+}
+
+// resolveHostname returns the IPv4 and IPv6 addresses of a hostname and the
+// lowest TTL of the answers. A name without address (NXDOMAIN or an empty
+// answer) is an error, it is more likely a DNS incident than a backend removal.
+func (g *GSLB) resolveHostname(ctx context.Context, hostname string) ([]string, time.Duration, error) {
+	server, err := g.hostnameResolverAddr()
+	if err != nil {
+		return nil, 0, err
+	}
+	var addresses []string
+	ttl := time.Duration(-1)
+	rcode := dns.RcodeSuccess
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		msg := new(dns.Msg)
+		msg.SetQuestion(dns.Fqdn(strings.ToLower(hostname)), qtype)
+		resp, _, err := (&dns.Client{}).ExchangeContext(ctx, msg, server)
+		if err == nil && resp.Truncated {
+			resp, _, err = (&dns.Client{Net: "tcp"}).ExchangeContext(ctx, msg, server)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		if resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError {
+			return nil, 0, fmt.Errorf("%s query failed: %s", dns.TypeToString[qtype], dns.RcodeToString[resp.Rcode])
+		}
+		if resp.Rcode == dns.RcodeNameError {
+			rcode = resp.Rcode
+		}
+		answerTTL := recordTTL(resp)
+		if ttl < 0 || answerTTL < ttl {
+			ttl = answerTTL
+		}
+		for _, rr := range resp.Answer {
+			switch rr := rr.(type) {
+			case *dns.A:
+				addresses = append(addresses, rr.A.String())
+			case *dns.AAAA:
+				addresses = append(addresses, rr.AAAA.String())
+			}
+		}
+	}
+	if len(addresses) == 0 {
+		return nil, 0, fmt.Errorf("no address found: %s", dns.RcodeToString[rcode])
+	}
+	return addresses, ttl, nil
+}
+
+// recordTTL returns the lowest TTL of the answer, CNAMEs included, or the
+// negative caching TTL of the SOA when there is no answer.
+func recordTTL(resp *dns.Msg) time.Duration {
+	ttl := int64(-1)
+	for _, rr := range resp.Answer {
+		if t := int64(rr.Header().Ttl); ttl < 0 || t < ttl {
+			ttl = t
+		}
+	}
+	if ttl < 0 {
+		for _, rr := range resp.Ns {
+			if soa, ok := rr.(*dns.SOA); ok {
+				ttl = int64(min(soa.Hdr.Ttl, soa.Minttl))
+			}
+		}
+	}
+	return time.Duration(max(ttl, 0)) * time.Second
+}
+
+// hostnameResolverAddr returns the DNS server resolving the backend hostnames,
+// the first one of /etc/resolv.conf unless set in the Corefile.
+func (g *GSLB) hostnameResolverAddr() (string, error) {
+	if g.HostnameResolver != "" {
+		if _, _, err := net.SplitHostPort(g.HostnameResolver); err != nil {
+			return net.JoinHostPort(g.HostnameResolver, "53"), nil
+		}
+		return g.HostnameResolver, nil
+	}
+	config, err := dns.ClientConfigFromFile("/etc/resolv.conf")
+	if err != nil || len(config.Servers) == 0 {
+		return "", fmt.Errorf("no DNS server configured")
+	}
+	return net.JoinHostPort(config.Servers[0], config.Port), nil
+}
@@ -0,0 +1,248 @@
+package gslb
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// testResolver is a DNS server answering with records which can be changed by the tests.
+type testResolver struct {
+	mutex   sync.Mutex
+	answers map[uint16][]string // Records by query type, in zone file format
+	rcode   int
+	addr    string
+}
+
+func newTestResolver(t *testing.T) *testResolver {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	resolver := &testResolver{answers: make(map[uint16][]string), addr: pc.LocalAddr().String()}
+	server := &dns.Server{PacketConn: pc, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		resolver.mutex.Lock()
+		defer resolver.mutex.Unlock()
+		m := new(dns.Msg)
+		m.SetRcode(r, resolver.rcode)
+		for _, record := range resolver.answers[r.Question[0].Qtype] {
+			rr, _ := dns.NewRR(record)
+			m.Answer = append(m.Answer, rr)
+		}
+		w.WriteMsg(m)
+	})}
+	go server.ActivateAndServe()
+	t.Cleanup(func() { server.Shutdown() })
+	return resolver
+}
+
+func (r *testResolver) set(rcode int, answers map[uint16][]string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.rcode = rcode
+	r.answers = answers
+}
+
+// resolveNow resolves the hostnames of the record right away and waits for the result.
+func resolveNow(g *GSLB, record *Record) {
+	record.mutex.Lock()
+	hostnames := append([]*hostnameBackend(nil), record.hostnames...)
+	record.mutex.Unlock()
+	for _, h := range hostnames {
+		g.resolveHostnameBackend(context.Background(), record, h)
+	}
+}
+
+func TestResolveHostname(t *testing.T) {
+	resolver := newTestResolver(t)
+	resolver.set(dns.RcodeSuccess, map[uint16][]string{
+		dns.TypeA: {
+			"lb.example.com. 300 IN CNAME lb-1234.elb.example.net.",
+			"lb-1234.elb.example.net. 60 IN A 192.0.2.10",
+			"lb-1234.elb.example.net. 60 IN A 192.0.2.11",
+		},
+		dns.TypeAAAA: {"lb-1234.elb.example.net. 30 IN AAAA 2001:db8::10"},
+	})
+	g := &GSLB{HostnameResolver: resolver.addr}
+
+	addresses, ttl, err := g.resolveHostname(context.Background(), "lb.example.com")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"192.0.2.10", "192.0.2.11", "2001:db8::10"}, addresses)
+	assert.Equal(t, 30*time.Second, ttl)
+
+	// A name without address is an error
+	resolver.set(dns.RcodeNameError, nil)
+	_, _, err = g.resolveHostname(context.Background(), "lb.example.com")
+	assert.ErrorContains(t, err, "NXDOMAIN")
+	resolver.set(dns.RcodeSuccess, nil)
+	_, _, err = g.resolveHostname(context.Background(), "lb.example.com")
+	assert.ErrorContains(t, err, "no address found")
+
+	resolver.set(dns.RcodeServerFailure, nil)
+	_, _, err = g.resolveHostname(context.Background(), "lb.example.com")
+	assert.ErrorContains(t, err, "SERVFAIL")
+}
+
+func TestHostnameRefresh(t *testing.T) {
+	g := &GSLB{HostnameRefreshMin: "10s", HostnameRefreshMax: "2m"}
+	assert.Equal(t, 10*time.Second, g.hostnameRefresh(0))
+	assert.Equal(t, 60*time.Second, g.hostnameRefresh(time.Minute))
+	assert.Equal(t, 2*time.Minute, g.hostnameRefresh(time.Hour))
+
+	g = &GSLB{}
+	assert.Equal(t, 5*time.Second, g.GetHostnameRefreshMin())
+	assert.Equal(t, 300*time.Second, g.GetHostnameRefreshMax())
+}
+
+func TestRecordHostnameBackends(t *testing.T) {
+	resolver := newTestResolver(t)
+	resolver.set(dns.RcodeSuccess, map[uint16][]string{
+		dns.TypeA: {"lb.example.com. 60 IN A 192.0.2.10", "lb.example.com. 60 IN A 192.0.2.11"},
+	})
+
+	var record Record
+	require.NoError(t, yaml.Unmarshal([]byte(`
+mode: round-robin
+backends:
+  - address: 10.0.0.1
+  - address: lb.example.com
+    priority: 2
+    tags: [cloud]
+`), &record))
+	record.Fqdn = "app.example.com."
+	require.Len(t, record.Backends, 1)
+	require.Len(t, record.hostnames, 1)
+
+	g := &GSLB{
+		HostnameResolver: resolver.addr,
+		Records:          map[string]map[string]*Record{"example.com.": {"app.example.com.": &record}},
+	}
+	resolveNow(g, &record)
+
+	addresses := func() []string {
+		record.mutex.RLock()
+		defer record.mutex.RUnlock()
+		var list []string
+		for _, backend := range record.Backends {
+			list = append(list, backend.GetAddress())
+		}
+		return list
+	}
+	assert.ElementsMatch(t, []string{"10.0.0.1", "192.0.2.10", "192.0.2.11"}, addresses())
+	resolved := record.Backends[1].(*Backend)
+	assert.Equal(t, "lb.example.com", resolved.Hostname)
+	assert.Equal(t, 2, resolved.Priority)
+	assert.Equal(t, []string{"cloud"}, resolved.Tags)
+	assert.Equal(t, "app.example.com.", resolved.Fqdn)
+
+	// The addresses rotate
+	resolver.set(dns.RcodeSuccess, map[uint16][]string{
+		dns.TypeA: {"lb.example.com. 60 IN A 192.0.2.11", "lb.example.com. 60 IN A 192.0.2.12"},
+	})
+	resolveNow(g, &record)
+	assert.ElementsMatch(t, []string{"10.0.0.1", "192.0.2.11", "192.0.2.12"}, addresses())
+	record.mutex.RLock()
+	assert.False(t, record.hostnames[0].nextResolve.IsZero())
+	record.mutex.RUnlock()
+
+	// The addresses are kept when the resolution fails or returns no address
+	resolver.set(dns.RcodeServerFailure, nil)
+	resolveNow(g, &record)
+	assert.ElementsMatch(t, []string{"10.0.0.1", "192.0.2.11", "192.0.2.12"}, addresses())
+	resolver.set(dns.RcodeNameError, nil)
+	resolveNow(g, &record)
+	assert.ElementsMatch(t, []string{"10.0.0.1", "192.0.2.11", "192.0.2.12"}, addresses())
+	resolver.set(dns.RcodeSuccess, nil)
+	resolveNow(g, &record)
+	assert.ElementsMatch(t, []string{"10.0.0.1", "192.0.2.11", "192.0.2.12"}, addresses())
+
+	// An address of a configured backend is not duplicated
+	resolver.set(dns.RcodeSuccess, map[uint16][]string{dns.TypeA: {"lb.example.com. 60 IN A 10.0.0.1"}})
+	resolveNow(g, &record)
+	assert.Equal(t, []string{"10.0.0.1"}, addresses())
+	assert.False(t, isResolved(record.Backends[0]))
+}
+
+func TestUpdateRecordHostnames(t *testing.T) {
+	decode := func(config string) *Record {
+		var record Record
+		require.NoError(t, yaml.Unmarshal([]byte(config), &record))
+		record.Fqdn = "app.example.com."
+		return &record
+	}
+	record := decode(`
+backends:
+  - address: 10.0.0.1
+  - address: lb.example.com
+    priority: 1
+`)
+	record.mutex.Lock()
+	record.applyHostnameAddresses(record.hostnames[0], []string{"192.0.2.10"})
+	record.mutex.Unlock()
+	require.Len(t, record.Backends, 2)
+
+	// The resolved backends are kept and updated with the new definition
+	record.updateRecord(decode(`
+backends:
+  - address: 10.0.0.1
+  - address: lb.example.com
+    priority: 5
+`))
+	require.Len(t, record.Backends, 2)
+	assert.Equal(t, "192.0.2.10", record.Backends[1].GetAddress())
+	assert.Equal(t, 5, record.Backends[1].GetPriority())
+	assert.Equal(t, []string{"192.0.2.10"}, record.hostnames[0].addresses)
+
+	// Removing the hostname removes its backends
+	record.updateRecord(decode(`
+backends:
+  - address: 10.0.0.1
+`))
+	require.Len(t, record.Backends, 1)
+	assert.Equal(t, "10.0.0.1", record.Backends[0].GetAddress())
+	assert.Empty(t, record.hostnames)
+}
+
+func TestHostnameBackendsScheduled(t *testing.T) {
+	resolver := newTestResolver(t)
+	resolver.set(dns.RcodeSuccess, map[uint16][]string{dns.TypeA: {"lb.example.com. 60 IN A 192.0.2.10"}})
+
+	var record Record
+	require.NoError(t, yaml.Unmarshal([]byte(`
+scrape_interval: 1h
+backends:
+  - address: lb.example.com
+`), &record))
+	record.Fqdn = "app.example.com."
+	g := &GSLB{
+		HostnameResolver: resolver.addr,
+		MaxStaggerStart:  "0s",
+		Records:          map[string]map[string]*Record{"example.com.": {"app.example.com.": &record}},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	g.startScheduler(ctx).schedule(ctx, &record)
+
+	// The resolved backend is checked without waiting for the record interval
+	assert.Eventually(t, func() bool {
+		record.mutex.RLock()
+		defer record.mutex.RUnlock()
+		return len(record.Backends) == 1 && record.Backends[0].IsHealthy()
+	}, 3*time.Second, 10*time.Millisecond)
+}
+
+func TestRecordInvalidBackendAddress(t *testing.T) {
+	var record Record
+	err := yaml.Unmarshal([]byte("backends:\n  - address: \"not a hostname!\"\n"), &record)
+	assert.Error(t, err)
+
+	assert.True(t, isHostname("lb-1234.elb.example.net"))
+	assert.True(t, isHostname("_service.example.com."))
+	assert.False(t, isHostname("lb..example.com"))
+	assert.False(t, isHostname("-lb.example.com"))
+}
@@ -52,7 +52,7 @@ func TestBackend_RunHealthChecks(t *testing.T) {
 	}
 
 	// Run the health checks (mocked to always return true)
-	backend.runHealthChecks(3, 5*time.Second)
+	backend.runHealthChecks(scrapeSettings{retries: 3, timeout: 5 * time.Second})
 
 	// Assert that the backend's Alive status is true (since the mock always returns true)
 	assert.True(t, backend.Alive)
@@ -44,3 +44,42 @@ func TestPrettyPrintStatus(t *testing.T) {
 		t.Errorf("pretty print failed:\nGot:\n%s\nWant:\n%s", pretty.String(), want)
 	}
 }
+
+func TestPrintHistory(t *testing.T) {
+	input := []byte(`{"record":"app.example.com.","address":"10.0.0.1","checks":[{"type":"tcp/80","results":[` +
+		`{"time":"2025-07-21T13:03:39Z","duration_ms":1.5,"success":true},` +
+		`{"time":"2025-07-21T13:03:29Z","duration_ms":5000,"success":false,"reason":"timeout","error":"i/o timeout"}]}]}`)
+	var out bytes.Buffer
+	if err := printHistory(&out, input); err != nil {
+		t.Fatalf("printHistory failed: %v", err)
+	}
+	want := "CHECK   TIME                  RESULT   DURATION  REASON   ERROR\n" +
+		"tcp/80  2025-07-21T13:03:39Z  success  1.5ms              \n" +
+		"tcp/80  2025-07-21T13:03:29Z  failure  5000.0ms  timeout  i/o timeout\n"
+	if out.String() != want {
+		t.Errorf("unexpected output:\nGot:\n%s\nWant:\n%s", out.String(), want)
+	}
+
+	if err := printHistory(&out, []byte(`{"error":"Backend not found"}`)); err == nil || err.Error() != "Backend not found" {
+		t.Errorf("expected API error, got %v", err)
+	}
+}
+
+func TestPrintRunResults(t *testing.T) {
+	input := []byte(`{"success":true,"results":[{"record":"app.example.com.","address":"10.0.0.1","alive":false,"checks":[` +
+		`{"type":"http/443","results":[{"time":"2025-07-21T13:03:29Z","duration_ms":12.25,"success":false,"reason":"protocol","error":"unexpected status code: got 503, want 200"}]}]}]}`)
+	var out bytes.Buffer
+	if err := printRunResults(&out, input); err != nil {
+		t.Fatalf("printRunResults failed: %v", err)
+	}
+	want := "RECORD            BACKEND   ALIVE  CHECK     RESULT   DURATION  REASON    ERROR\n" +
+		"app.example.com.  10.0.0.1  false  http/443  failure  12.2ms    protocol  unexpected status code: got 503, want 200\n"
+	if out.String() != want {
+		t.Errorf("unexpected output:\nGot:\n%s\nWant:\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	if err := printRunResults(&out, []byte(`{"success":true,"results":[]}`)); err != nil || out.String() != "No backends matched your criteria.\n" {
+		t.Errorf("unexpected output for no match: %q, %v", out.String(), err)
+	}
+}
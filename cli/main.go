@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"sort"
 	"strings"
@@ -105,6 +106,8 @@ func main() {
 	switch os.Args[1] {
 	case "backends":
 		backendsCmd(os.Args[2:], api, cfg)
+	case "healthcheck":
+		healthcheckCmd(os.Args[2:], api, cfg)
 	case "status":
 		statusCmd(api, cfg)
 	default:
@@ -119,6 +122,8 @@ func usage() {
 Commands:
   backends enable   [--tags tag1,tag2] [--address addr] [--location loc]
   backends disable  [--tags tag1,tag2] [--address addr] [--location loc]
+  backends history  --record fqdn --address addr
+  healthcheck run   [--record fqdn] [--address addr] [--tags tag1,tag2] [--location loc]
   status
 `)
 }
@@ -129,6 +134,10 @@ func backendsCmd(args []string, api string, cfg Config) {
 		os.Exit(1)
 	}
 	sub := args[0]
+	if sub == "history" {
+		historyCmd(args[1:], api, cfg)
+		return
+	}
 	fs := flag.NewFlagSet("backends "+sub, flag.ExitOnError)
 	tags := fs.String("tags", "", "Comma-separated list of tags")
 	address := fs.String("address", "", "Backend address")
@@ -217,6 +226,176 @@ func backendsCmd(args []string, api string, cfg Config) {
 	fmt.Println(string(data))
 }
 
+func historyCmd(args []string, api string, cfg Config) {
+	fs := flag.NewFlagSet("backends history", flag.ExitOnError)
+	record := fs.String("record", "", "Record name")
+	address := fs.String("address", "", "Backend address")
+	fs.Parse(args)
+	if *record == "" || *address == "" {
+		usage()
+		os.Exit(1)
+	}
+
+	endpoint := fmt.Sprintf("/api/records/%s/backends/%s/checks", url.PathEscape(*record), url.PathEscape(*address))
+	req, err := http.NewRequest("GET", api+endpoint, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "API error: %v\n", err)
+		os.Exit(2)
+	}
+	addAuth(req, cfg)
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "API error: %v\n", err)
+		os.Exit(2)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if os.Getenv("GSLBCTL_DEBUG") != "" {
+		fmt.Fprintf(os.Stderr, "[gslbctl debug] Raw API response: %s\n", string(data))
+	}
+	if err := printHistory(os.Stdout, data); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+}
+
+// printHistory prints the health check history returned by the API as a table.
+func printHistory(out io.Writer, data []byte) error {
+	type result struct {
+		Time       string  `json:"time"`
+		DurationMs float64 `json:"duration_ms"`
+		Success    bool    `json:"success"`
+		Reason     string  `json:"reason"`
+		Error      string  `json:"error"`
+	}
+	type check struct {
+		Type    string   `json:"type"`
+		Results []result `json:"results"`
+	}
+	var r struct {
+		Checks []check `json:"checks"`
+		Error  string  `json:"error"`
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return fmt.Errorf("invalid API response: %s", string(data))
+	}
+	if r.Error != "" {
+		return fmt.Errorf("%s", r.Error)
+	}
+	w := tabwriter.NewWriter(out, 2, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tTIME\tRESULT\tDURATION\tREASON\tERROR")
+	for _, c := range r.Checks {
+		for _, res := range c.Results {
+			status := "success"
+			if !res.Success {
+				status = "failure"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%.1fms\t%s\t%s\n", c.Type, res.Time, status, res.DurationMs, res.Reason, res.Error)
+		}
+	}
+	return w.Flush()
+}
+
+func healthcheckCmd(args []string, api string, cfg Config) {
+	if len(args) < 1 || args[0] != "run" {
+		usage()
+		os.Exit(1)
+	}
+	fs := flag.NewFlagSet("healthcheck run", flag.ExitOnError)
+	record := fs.String("record", "", "Record name")
+	address := fs.String("address", "", "Backend address")
+	tags := fs.String("tags", "", "Comma-separated list of tags")
+	location := fs.String("location", "", "Location string")
+	fs.Parse(args[1:])
+
+	var body = make(map[string]interface{})
+	if *record != "" {
+		body["record"] = *record
+	}
+	if *address != "" {
+		body["address"] = *address
+	}
+	if *tags != "" {
+		body["tags"] = strings.Split(*tags, ",")
+	}
+	if *location != "" {
+		body["location"] = *location
+	}
+	jsonBody, _ := json.Marshal(body)
+
+	req, err := http.NewRequest("POST", api+"/api/healthchecks/run", bytes.NewReader(jsonBody))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "API error: %v\n", err)
+		os.Exit(2)
+	}
+	addAuth(req, cfg)
+	req.Header.Set("Content-Type", "application/json")
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "API error: %v\n", err)
+		os.Exit(2)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if os.Getenv("GSLBCTL_DEBUG") != "" {
+		fmt.Fprintf(os.Stderr, "[gslbctl debug] Raw API response: %s\n", string(data))
+	}
+	if err := printRunResults(os.Stdout, data); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+}
+
+// printRunResults prints the results of an on-demand health check run as a table.
+func printRunResults(out io.Writer, data []byte) error {
+	type result struct {
+		DurationMs float64 `json:"duration_ms"`
+		Success    bool    `json:"success"`
+		Reason     string  `json:"reason"`
+		Error      string  `json:"error"`
+	}
+	type check struct {
+		Type    string   `json:"type"`
+		Results []result `json:"results"`
+	}
+	type backend struct {
+		Record  string  `json:"record"`
+		Address string  `json:"address"`
+		Alive   bool    `json:"alive"`
+		Checks  []check `json:"checks"`
+	}
+	var r struct {
+		Results []backend `json:"results"`
+		Error   string    `json:"error"`
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return fmt.Errorf("invalid API response: %s", string(data))
+	}
+	if r.Error != "" {
+		return fmt.Errorf("%s", r.Error)
+	}
+	if len(r.Results) == 0 {
+		fmt.Fprintln(out, "No backends matched your criteria.")
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 2, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "RECORD\tBACKEND\tALIVE\tCHECK\tRESULT\tDURATION\tREASON\tERROR")
+	for _, be := range r.Results {
+		for _, c := range be.Checks {
+			for _, res := range c.Results {
+				status := "success"
+				if !res.Success {
+					status = "failure"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%v\t%s\t%s\t%.1fms\t%s\t%s\n", be.Record, be.Address, be.Alive, c.Type, status, res.DurationMs, res.Reason, res.Error)
+			}
+		}
+	}
+	return w.Flush()
+}
+
 func statusCmd(api string, cfg Config) {
 	req, err := http.NewRequest("GET", api+"/api/overview", nil)
 	if err != nil {
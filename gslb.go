@@ -18,7 +18,7 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-var log = clog.NewWithPlugin("gslb")
+var log = redactingLogger{clog.NewWithPlugin("gslb")}
 
 type GSLB struct {
 	Next                plugin.Handler
@@ -26,29 +26,45 @@ type GSLB struct {
 	Records             map[string]map[string]*Record // zone -> fqdn -> record
 	HealthcheckProfiles map[string]*HealthCheck       `yaml:"healthcheck_profiles"`
 
-	Zone                      string   // Zone attendue pour la vérification des records
-	LastResolution            sync.Map // key: domain (string), value: time.Time
-	RoundRobinIndex           sync.Map
-	MaxStaggerStart           string
-	BatchSizeStart            int
-	ResolutionIdleTimeout     string
-	ResolutionIdleMultiplier  int // Multiplier for slow healthcheck interval
-	HealthcheckIdleMultiplier int // Multiplier for slow healthcheck interval
-	Mutex                     sync.RWMutex
-	UseEDNSCSubnet            bool
-	LocationMap               map[string]string
-	GeoIPCountryDB            *geoip2.Reader // Loaded MaxMind DB (country)
-	GeoIPCityDB               *geoip2.Reader // Loaded MaxMind DB (city)
-	GeoIPASNDB                *geoip2.Reader // Loaded MaxMind DB (ASN)
-	APIEnable                 bool           // Enable/disable API HTTP server
-	APICertPath               string         // TLS certificate path for API
-	APIKeyPath                string         // TLS key path for API
-	APIListenAddr             string         // API listen address (default 0.0.0.0)
-	APIListenPort             string         // API listen port (default 8080)
-	APIBasicUser              string         // HTTP Basic Auth username (optional)
-	APIBasicPass              string         // HTTP Basic Auth password (optional)
+	Zone                         string   // Zone attendue pour la vérification des records
+	LastResolution               sync.Map // key: domain (string), value: time.Time
+	RoundRobinIndex              sync.Map
+	MaxStaggerStart              string // Maximum jitter before the first healthcheck run of a record
+	BatchSizeStart               int    // Deprecated: records are no longer started in batches
+	ResolutionIdleTimeout        string
+	ResolutionIdleMultiplier     int    // Multiplier for slow healthcheck interval
+	HealthcheckIdleMultiplier    int    // Multiplier for slow healthcheck interval
+	HealthcheckWorkers           int    // Number of workers running healthchecks
+	HealthcheckMaxPerDestination int    // Maximum concurrent healthchecks per backend address (0 = unlimited)
+	HealthcheckBackoffMax        string // Maximum interval of a failing healthcheck (0 = no backoff)
+	HealthcheckFastInterval      string // Healthcheck interval after a backend state change (0 = disabled)
+	HealthcheckFastWindow        string // Duration of the fast healthchecks after a backend state change
+	Mutex                        sync.RWMutex
+	UseEDNSCSubnet               bool
+	LocationMap                  map[string]string
+	GeoIPCountryDB               *geoip2.Reader // Loaded MaxMind DB (country)
+	GeoIPCityDB                  *geoip2.Reader // Loaded MaxMind DB (city)
+	GeoIPASNDB                   *geoip2.Reader // Loaded MaxMind DB (ASN)
+	APIEnable                    bool           // Enable/disable API HTTP server
+	APICertPath                  string         // TLS certificate path for API
+	APIKeyPath                   string         // TLS key path for API
+	APIListenAddr                string         // API listen address (default 0.0.0.0)
+	APIListenPort                string         // API listen port (default 8080)
+	APIBasicUser                 string         // HTTP Basic Auth username (optional)
+	APIBasicPass                 string         // HTTP Basic Auth password (optional)
 	// DisableTXT disables TXT record resolution if set to true
-	DisableTXT bool
+	DisableTXT         bool
+	PushDNSListenAddr  string            // Listen address of the DNS UPDATE server for push healthchecks (disabled if empty)
+	PushTSIGKeys       map[string]string // TSIG key name -> base64 secret accepted by the DNS UPDATE server
+	StateFile          string            // File where the health of backends is persisted (disabled if empty)
+	StateSaveInterval  string            // Interval between two writes of the state file
+	HostnameResolver   string            // DNS server resolving the backend hostnames (default: first server of /etc/resolv.conf)
+	HostnameRefreshMin string            // Minimum interval between two resolutions of a backend hostname
+	HostnameRefreshMax string            // Maximum interval between two resolutions of a backend hostname
+	HealthcheckSource  SourceBinding     // Source binding of the probes, resolved into each health check when the records are loaded
+
+	scheduler     *healthcheckScheduler
+	schedulerOnce sync.Once
 }
 
 func (g *GSLB) Name() string { return "gslb" }
@@ -163,10 +179,20 @@ func (g *GSLB) processHealthchecks(healthchecks interface{}) ([]interface{}, err
 				if err != nil {
 					return nil, err
 				}
-				result = append(result, map[string]interface{}{
+				resolved := map[string]interface{}{
 					"type":   profile.Type,
 					"params": profile.Params,
-				})
+				}
+				if profile.ScrapeInterval != "" {
+					resolved["scrape_interval"] = profile.ScrapeInterval
+				}
+				if profile.ScrapeTimeout != "" {
+					resolved["scrape_timeout"] = profile.ScrapeTimeout
+				}
+				if profile.ScrapeRetries != nil {
+					resolved["scrape_retries"] = *profile.ScrapeRetries
+				}
+				result = append(result, resolved)
 			default:
 				// It's a full healthcheck object
 				result = append(result, item)
@@ -477,11 +503,14 @@ func (g *GSLB) updateRecords(ctx context.Context, newGSLB *GSLB) {
 				g.Records[zone][fqdn] = newRecord
 				log.Infof("Added new record for zone %s: %s", zone, fqdn)
 				newRecord.updateRecordHealthStatus()
-				go newRecord.scrapeBackends(ctx, g)
+				recordCtx, cancel := context.WithCancel(ctx)
+				newRecord.cancelFunc = cancel
+				g.startScheduler(ctx).schedule(recordCtx, newRecord)
 			} else {
 				log.Infof("Reloading record %s in zone %s", fqdn, zone)
 				oldRecord.updateRecord(newRecord)
 				oldRecord.updateRecordHealthStatus()
+				g.startScheduler(ctx).wake(oldRecord)
 			}
 		}
 		// Remove records from old zone that are no longer present in newGSLB.Records
@@ -496,6 +525,9 @@ func (g *GSLB) updateRecords(ctx context.Context, newGSLB *GSLB) {
 		}
 	}
 
+	g.checkDependencies()
+	g.restorePushReports()
+
 	// Update metrics
 	g.updateMetrics()
 }
@@ -510,20 +542,22 @@ func (g *GSLB) initializeRecordsFromFiles(ctx context.Context, zoneFiles map[str
 		}
 		log.Infof("Loaded %d records for zone %s", len(g.Records[zone]), zone)
 	}
-	groups := g.batchRecords(g.BatchSizeStart)
-	for i, group := range groups {
-		go func(group []*Record, delay time.Duration) {
-			time.Sleep(delay)
-			for _, record := range group {
-				domain := record.Fqdn
-				recordCtx, cancel := context.WithCancel(ctx)
-				record.cancelFunc = cancel
-				log.Debugf("[%s] Starting health checks for backends", domain)
-				// Initialize health status for existing record
-				record.updateRecordHealthStatus()
-				go record.scrapeBackends(recordCtx, g)
-			}
-		}(group, time.Duration(i)*g.staggerDelay(len(groups)))
+	g.checkDependencies()
+	// Restore the last known health before the first health checks
+	g.loadStateFile()
+	g.restorePushReports()
+
+	scheduler := g.startScheduler(ctx)
+	for _, records := range g.Records {
+		for domain, record := range records {
+			record.Fqdn = domain
+			recordCtx, cancel := context.WithCancel(ctx)
+			record.cancelFunc = cancel
+			log.Debugf("[%s] Starting health checks for backends", domain)
+			// Initialize health status for existing record
+			record.updateRecordHealthStatus()
+			scheduler.schedule(recordCtx, record)
+		}
 	}
 
 	// Update metrics
@@ -540,57 +574,73 @@ func (g *GSLB) updateMetrics() {
 	}
 	SetRecordsTotal(float64(totalRecords))
 
-	// Set total backends configured
+	// Set total backends and healthchecks configured, backends resolved from hostnames included
 	totalBackends := 0
-	for _, records := range g.Records {
-		for _, record := range records {
-			totalBackends += len(record.Backends)
-		}
-	}
-	SetBackendsTotal(float64(totalBackends))
-
-	// Set total healthchecks configured
 	totalHealthchecks := 0
 	for _, records := range g.Records {
 		for _, record := range records {
+			record.mutex.RLock()
+			totalBackends += len(record.Backends)
 			for _, backend := range record.Backends {
 				totalHealthchecks += len(backend.GetHealthChecks())
 			}
+			record.mutex.RUnlock()
 		}
 	}
+	SetBackendsTotal(float64(totalBackends))
 	SetHealthchecksTotal(float64(totalHealthchecks))
 }
 
-func (g *GSLB) batchRecords(batchSize int) [][]*Record {
-	var groups [][]*Record
-	var current []*Record
+// startScheduler returns the healthcheck scheduler, starting it with the
+// resolver of the backend hostnames and the evaluation of the record
+// dependencies on first use.
+func (g *GSLB) startScheduler(ctx context.Context) *healthcheckScheduler {
+	g.schedulerOnce.Do(func() {
+		g.scheduler = newHealthcheckScheduler(g)
+		g.scheduler.start(ctx)
+		go g.runHostnameResolver(ctx)
+		go g.runDependencyEvaluator(ctx)
+	})
+	return g.scheduler
+}
 
-	for _, records := range g.Records {
-		for domain, record := range records {
-			record.Fqdn = domain
-			current = append(current, record)
-			if len(current) == batchSize {
-				groups = append(groups, current)
-				current = nil
-			}
-		}
-	}
+func (g *GSLB) updateLastResolutionTime(domain string) {
+	g.LastResolution.Store(domain, time.Now())
+}
 
-	if len(current) > 0 {
-		groups = append(groups, current)
-	}
-	return groups
+// scrapeSettings returns the health check settings of a record, including the
+// global backoff and fast probing. The caller must hold the record mutex.
+func (g *GSLB) scrapeSettings(r *Record) scrapeSettings {
+	settings := r.scrapeSettings()
+	settings.backoffMax = g.GetHealthcheckBackoffMax()
+	settings.fastInterval = g.GetHealthcheckFastInterval()
+	settings.fastWindow = g.GetHealthcheckFastWindow()
+	return settings
 }
 
-func (g *GSLB) staggerDelay(totalBatches int) time.Duration {
-	if totalBatches == 0 {
-		return 0
-	}
-	return g.GetMaxStaggerStart() / time.Duration(totalBatches)
+// GetHealthcheckBackoffMax returns the maximum interval of a failing health check, 0 when disabled.
+func (g *GSLB) GetHealthcheckBackoffMax() time.Duration {
+	return parseDurationWithDefault(g.HealthcheckBackoffMax, "0s")
 }
 
-func (g *GSLB) updateLastResolutionTime(domain string) {
-	g.LastResolution.Store(domain, time.Now())
+// GetHealthcheckFastInterval returns the health check interval after a state change, 0 when disabled.
+func (g *GSLB) GetHealthcheckFastInterval() time.Duration {
+	return parseDurationWithDefault(g.HealthcheckFastInterval, "0s")
+}
+
+// GetHealthcheckFastWindow returns how long health checks run at the fast interval after a state change.
+func (g *GSLB) GetHealthcheckFastWindow() time.Duration {
+	return parseDurationWithDefault(g.HealthcheckFastWindow, "30s")
+}
+
+// GetHostnameRefreshMin returns the minimum interval between two resolutions of a backend hostname.
+func (g *GSLB) GetHostnameRefreshMin() time.Duration {
+	return parseDurationWithDefault(g.HostnameRefreshMin, "5s")
+}
+
+// GetHostnameRefreshMax returns the maximum interval between two resolutions of a backend hostname.
+func (g *GSLB) GetHostnameRefreshMax() time.Duration {
+	return parseDurationWithDefault(g.HostnameRefreshMax, "300s")
 }
 
 func (g *GSLB) GetMaxStaggerStart() time.Duration {
@@ -715,6 +765,7 @@ func loadConfigFile(gslb *GSLB, fileName string, zone string) error {
 			return fmt.Errorf("failed to unmarshal record %s: %w", fqdn, err)
 		}
 		record.Fqdn = fqdn
+		record.applySourceBinding(gslb.HealthcheckSource)
 		gslb.Records[zone][fqdn] = &record
 	}
 	return nil
@@ -2,6 +2,7 @@ package gslb
 
 import (
 	"fmt"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -36,38 +37,49 @@ func healthChecksEqual(h1, h2 []GenericHealthCheck) bool {
 }
 
 type HealthCheck struct {
-	Type   string                 `yaml:"type"`
-	Params map[string]interface{} `yaml:"params"`
+	Type           string                 `yaml:"type"`
+	Params         map[string]interface{} `yaml:"params"`
+	ScrapeInterval string                 `yaml:"scrape_interval"` // Overrides the backend and record scrape_interval
+	ScrapeTimeout  string                 `yaml:"scrape_timeout"`  // Overrides the backend and record scrape_timeout
+	ScrapeRetries  *int                   `yaml:"scrape_retries"`  // Overrides the backend and record scrape_retries
+}
+
+// scrapeOverrides returns the scrape settings of the health check.
+func (hc *HealthCheck) scrapeOverrides() scrapeOverrides {
+	return scrapeOverrides{Interval: hc.ScrapeInterval, Timeout: hc.ScrapeTimeout, Retries: hc.ScrapeRetries}
 }
 
 // ResolveProfile resolves a healthcheck profile to a concrete HealthCheck
 func ResolveHealthcheckProfile(profileName string, localProfiles map[string]*HealthCheck) (*HealthCheck, error) {
 	if localProfiles != nil {
 		if profile, exists := localProfiles[profileName]; exists {
-			return &HealthCheck{
-				Type:   profile.Type,
-				Params: profile.Params,
-			}, nil
+			resolved := *profile
+			return &resolved, nil
 		}
 	}
 	if GlobalHealthcheckProfiles != nil {
 		if profile, exists := GlobalHealthcheckProfiles[profileName]; exists {
-			return &HealthCheck{
-				Type:   profile.Type,
-				Params: profile.Params,
-			}, nil
+			resolved := *profile
+			return &resolved, nil
 		}
 	}
 	return nil, fmt.Errorf("healthcheck profile '%s' not found", profileName)
 }
 
 func (hc *HealthCheck) ToSpecificHealthCheck() (GenericHealthCheck, error) {
+	// Secret references are resolved here only, hc.Params keeps them as written
+	resolved, err := resolveSecrets(hc.Params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve healthcheck params: %w", err)
+	}
+	params, _ := resolved.(map[string]interface{})
+
 	switch hc.Type {
 	case "http":
 		var httpCheck HTTPHealthCheck
 		httpCheck.SetDefault()
 
-		paramsYaml, err := yaml.Marshal(hc.Params) // Serialize `hc.Params` to YAML
+		paramsYaml, err := yaml.Marshal(params) // Serialize `params` to YAML
 		if err != nil {
 			return nil, fmt.Errorf("failed to serialize healthcheck params: %w", err)
 		}
@@ -81,7 +93,7 @@ func (hc *HealthCheck) ToSpecificHealthCheck() (GenericHealthCheck, error) {
 		var icmpCheck ICMPHealthCheck
 		icmpCheck.SetDefault()
 
-		paramsYaml, err := yaml.Marshal(hc.Params) // Serialize `hc.Params` to YAML
+		paramsYaml, err := yaml.Marshal(params) // Serialize `params` to YAML
 		if err != nil {
 			return nil, fmt.Errorf("failed to serialize healthcheck params: %w", err)
 		}
@@ -95,7 +107,7 @@ func (hc *HealthCheck) ToSpecificHealthCheck() (GenericHealthCheck, error) {
 		var tcpCheck TCPHealthCheck
 		tcpCheck.SetDefault()
 
-		paramsYaml, err := yaml.Marshal(hc.Params) // Serialize `hc.Params` to YAML
+		paramsYaml, err := yaml.Marshal(params) // Serialize `params` to YAML
 		if err != nil {
 			return nil, fmt.Errorf("failed to serialize healthcheck params: %w", err)
 		}
@@ -105,10 +117,24 @@ func (hc *HealthCheck) ToSpecificHealthCheck() (GenericHealthCheck, error) {
 		}
 		return &tcpCheck, nil
 
+	case "udp":
+		var udpCheck UDPHealthCheck
+		udpCheck.SetDefault()
+
+		paramsYaml, err := yaml.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize healthcheck params: %w", err)
+		}
+		err = yaml.Unmarshal(paramsYaml, &udpCheck)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode UDP params: %w", err)
+		}
+		return &udpCheck, nil
+
 	case "mysql":
 		var mysqlCheck MySQLHealthCheck
 		mysqlCheck.SetDefault()
-		paramsYaml, err := yaml.Marshal(hc.Params)
+		paramsYaml, err := yaml.Marshal(params)
 		if err != nil {
 			return nil, fmt.Errorf("failed to serialize healthcheck params: %w", err)
 		}
@@ -118,10 +144,23 @@ func (hc *HealthCheck) ToSpecificHealthCheck() (GenericHealthCheck, error) {
 		}
 		return &mysqlCheck, nil
 
+	case "postgres":
+		var postgresCheck PostgresHealthCheck
+		postgresCheck.SetDefault()
+		paramsYaml, err := yaml.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize healthcheck params: %w", err)
+		}
+		err = yaml.Unmarshal(paramsYaml, &postgresCheck)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode PostgreSQL params: %w", err)
+		}
+		return &postgresCheck, nil
+
 	case "grpc":
 		var grpcCheck GRPCHealthCheck
 		grpcCheck.SetDefault()
-		paramsYaml, err := yaml.Marshal(hc.Params)
+		paramsYaml, err := yaml.Marshal(params)
 		if err != nil {
 			return nil, fmt.Errorf("failed to serialize healthcheck params: %w", err)
 		}
@@ -131,11 +170,66 @@ func (hc *HealthCheck) ToSpecificHealthCheck() (GenericHealthCheck, error) {
 		}
 		return &grpcCheck, nil
 
+	case "tls":
+		var tlsCheck TLSHealthCheck
+		tlsCheck.SetDefault()
+		paramsYaml, err := yaml.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize healthcheck params: %w", err)
+		}
+		err = yaml.Unmarshal(paramsYaml, &tlsCheck)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode TLS params: %w", err)
+		}
+		return &tlsCheck, nil
+
+	case "exec":
+		var execCheck ExecHealthCheck
+		execCheck.SetDefault()
+		paramsYaml, err := yaml.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize healthcheck params: %w", err)
+		}
+		err = yaml.Unmarshal(paramsYaml, &execCheck)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode exec params: %w", err)
+		}
+		return &execCheck, nil
+
+	case "push":
+		var pushCheck PushHealthCheck
+		pushCheck.SetDefault()
+		paramsYaml, err := yaml.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize healthcheck params: %w", err)
+		}
+		err = yaml.Unmarshal(paramsYaml, &pushCheck)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode push params: %w", err)
+		}
+		if _, err := time.ParseDuration(pushCheck.TTL); err != nil {
+			return nil, fmt.Errorf("invalid push ttl: %w", err)
+		}
+		return &pushCheck, nil
+
+	case "prometheus":
+		var prometheusCheck PrometheusHealthCheck
+		prometheusCheck.SetDefault()
+		paramsYaml, err := yaml.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize healthcheck params: %w", err)
+		}
+		err = yaml.Unmarshal(paramsYaml, &prometheusCheck)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode Prometheus params: %w", err)
+		}
+		return &prometheusCheck, nil
+
 	case "lua":
 		var luaCheck LuaHealthCheck
 		luaCheck.SetDefault()
 
-		paramsYaml, err := yaml.Marshal(hc.Params)
+		paramsYaml, err := yaml.Marshal(params)
 		if err != nil {
 			return nil, fmt.Errorf("failed to serialize healthcheck params: %w", err)
 		}
@@ -143,6 +237,10 @@ func (hc *HealthCheck) ToSpecificHealthCheck() (GenericHealthCheck, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to decode Lua params: %w", err)
 		}
+		// Compile once per configuration load, a syntax error rejects the configuration
+		if _, err := luaCheck.compile(); err != nil {
+			return nil, fmt.Errorf("invalid Lua healthcheck: %w", err)
+		}
 		return &luaCheck, nil
 
 	default:
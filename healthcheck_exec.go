@@ -0,0 +1,183 @@
+package gslb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/creasty/defaults"
+)
+
+// Nagios plugin exit codes, any other code is critical or unknown.
+const (
+	execExitOK      = 0
+	execExitWarning = 1
+)
+
+// maxExecOutput bounds the command output kept for logs and the overview API.
+const maxExecOutput = 4096
+
+// ExecHealthCheck runs an external command, following the Nagios plugin conventions.
+type ExecHealthCheck struct {
+	Command           string            `yaml:"command"`                             // Path of the command to run
+	Args              []string          `yaml:"args"`                                // Command arguments
+	Env               map[string]string `yaml:"env"`                                 // Additional environment variables
+	Timeout           string            `yaml:"timeout" default:"5s"`                // Hard timeout, the process group is killed after it
+	WarningIsDegraded bool              `yaml:"warning_is_degraded" default:"false"` // If true, exit code 1 keeps the backend healthy but degraded
+}
+
+// SetDefault applies default values to ExecHealthCheck fields.
+func (h *ExecHealthCheck) SetDefault() {
+	defaults.Set(h)
+}
+
+// GetType returns the type of the health check as a string.
+func (h *ExecHealthCheck) GetType() string {
+	return "exec"
+}
+
+// environment returns the process environment with the backend fields as GSLB_* variables.
+func (h *ExecHealthCheck) environment(backend *Backend, fqdn string) []string {
+	env := append(os.Environ(),
+		"GSLB_FQDN="+fqdn,
+		"GSLB_ADDRESS="+backend.Address,
+		"GSLB_DESCRIPTION="+backend.Description,
+		"GSLB_PRIORITY="+strconv.Itoa(backend.Priority),
+		"GSLB_WEIGHT="+strconv.Itoa(backend.Weight),
+		"GSLB_TAGS="+strings.Join(backend.Tags, ","),
+		"GSLB_LOCATION="+backend.Location,
+		"GSLB_COUNTRY="+backend.Country,
+		"GSLB_CITY="+backend.City,
+		"GSLB_ASN="+backend.ASN,
+	)
+	for key, value := range h.Env {
+		env = append(env, key+"="+value)
+	}
+	return env
+}
+
+// run executes the command once and returns its exit code and trimmed output.
+func (h *ExecHealthCheck) run(env []string, timeout time.Duration) (int, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, h.Command, h.Args...)
+	cmd.Env = env
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	killProcessGroupOnCancel(cmd)
+	// Do not wait for children which inherited the output pipes
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	out := strings.TrimSpace(output.String())
+	if len(out) > maxExecOutput {
+		out = out[:maxExecOutput]
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return -1, out, fmt.Errorf("command timed out after %s: %w", timeout, context.DeadlineExceeded)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), out, nil
+	}
+	if err != nil {
+		return -1, out, err
+	}
+	return execExitOK, out, nil
+}
+
+// PerformCheck runs the command and maps its exit code to the backend health.
+func (h *ExecHealthCheck) PerformCheck(backend *Backend, fqdn string, maxRetries int) bool {
+	typeStr := h.GetType()
+	address := backend.Address
+	start := time.Now()
+	result := false
+	defer func() {
+		ObserveHealthcheck(fqdn, typeStr, address, start, result)
+	}()
+
+	timeout, err := time.ParseDuration(h.Timeout)
+	if err != nil {
+		log.Errorf("[%s] invalid timeout format: %v", fqdn, err)
+		backend.ReportFailure(typeStr, "timeout", err)
+		return false
+	}
+	if h.Command == "" {
+		log.Errorf("[%s] exec healthcheck has no command", fqdn)
+		backend.ReportFailure(typeStr, "other", errors.New("no command"))
+		return false
+	}
+
+	env := h.environment(backend, fqdn)
+	for retry := 0; retry <= maxRetries; retry++ {
+		code, output, err := h.run(env, timeout)
+		backend.SetCheckDetail(typeStr, output)
+		if err != nil {
+			log.Debugf("[%s] exec healthcheck failed (retries=%d/%d): [backend=%s command=%s] %v: %s", fqdn, retry, maxRetries, address, h.Command, err, output)
+			if retry == maxRetries {
+				reason := "other"
+				if errors.Is(err, context.DeadlineExceeded) {
+					reason = "timeout"
+				}
+				backend.ReportFailure(typeStr, reason, err)
+				return false
+			}
+			continue
+		}
+
+		switch {
+		case code == execExitOK:
+			log.Debugf("[%s] exec healthcheck success [backend=%s command=%s]: %s", fqdn, address, h.Command, output)
+			result = true
+			return true
+		case code == execExitWarning && h.WarningIsDegraded:
+			log.Debugf("[%s] exec healthcheck warning, backend degraded [backend=%s command=%s]: %s", fqdn, address, h.Command, output)
+			backend.ReportWarning()
+			result = true
+			return true
+		}
+
+		log.Debugf("[%s] exec healthcheck failed (retries=%d/%d): [backend=%s command=%s] exit code %d: %s", fqdn, retry, maxRetries, address, h.Command, code, output)
+		if retry == maxRetries {
+			backend.ReportFailure(typeStr, "protocol", fmt.Errorf("exit code %d", code))
+			return false
+		}
+	}
+
+	backend.ReportFailure(typeStr, "other", nil)
+	return false
+}
+
+// Equals compares two ExecHealthCheck objects for equality.
+func (h *ExecHealthCheck) Equals(other GenericHealthCheck) bool {
+	otherExec, ok := other.(*ExecHealthCheck)
+	if !ok {
+		return false
+	}
+	if h.Command != otherExec.Command ||
+		h.Timeout != otherExec.Timeout ||
+		h.WarningIsDegraded != otherExec.WarningIsDegraded ||
+		len(h.Args) != len(otherExec.Args) ||
+		len(h.Env) != len(otherExec.Env) {
+		return false
+	}
+	for i := range h.Args {
+		if h.Args[i] != otherExec.Args[i] {
+			return false
+		}
+	}
+	for key, value := range h.Env {
+		if otherValue, exists := otherExec.Env[key]; !exists || value != otherValue {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package gslb
+
+import "os/exec"
+
+// killProcessGroupOnCancel keeps the default behavior, only the command itself is killed.
+func killProcessGroupOnCancel(cmd *exec.Cmd) {}
@@ -0,0 +1,78 @@
+package gslb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecHealthCheck(t *testing.T) {
+	RegisterMetrics()
+	backend := &Backend{Address: "10.0.0.1", Fqdn: "app.example.com.", Tags: []string{"edge", "eu"}}
+	newCheck := func(script string) *ExecHealthCheck {
+		return &ExecHealthCheck{Command: "/bin/sh", Args: []string{"-c", script}, Timeout: "2s"}
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		hc := newCheck(`echo "OK - $GSLB_ADDRESS $GSLB_TAGS $CHECK_MODE"`)
+		hc.Env = map[string]string{"CHECK_MODE": "deep"}
+		assert.True(t, hc.PerformCheck(backend, "app.example.com.", 0))
+		assert.Equal(t, "OK - 10.0.0.1 edge,eu deep", backend.GetCheckDetails()["exec"])
+	})
+
+	t.Run("Critical", func(t *testing.T) {
+		assert.False(t, newCheck(`echo "CRITICAL - down"; exit 2`).PerformCheck(backend, "app.example.com.", 1))
+		assert.Equal(t, "CRITICAL - down", backend.GetCheckDetails()["exec"])
+	})
+
+	t.Run("Warning", func(t *testing.T) {
+		hc := newCheck(`echo "WARNING - slow"; exit 1`)
+		assert.False(t, hc.PerformCheck(backend, "app.example.com.", 0))
+
+		hc.WarningIsDegraded = true
+		assert.True(t, hc.PerformCheck(backend, "app.example.com.", 0))
+		assert.True(t, backend.warningReported)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		hc := newCheck(`sleep 30 & sleep 30`)
+		hc.Timeout = "200ms"
+		start := time.Now()
+		assert.False(t, hc.PerformCheck(backend, "app.example.com.", 0))
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		hc := &ExecHealthCheck{Command: "/nonexistent/check_app", Timeout: "1s"}
+		assert.False(t, hc.PerformCheck(backend, "app.example.com.", 0))
+	})
+}
+
+func TestExecHealthCheck_Degraded(t *testing.T) {
+	backend := &Backend{
+		Address: "10.0.0.1",
+		Fqdn:    "app.example.com.",
+		HealthChecks: []GenericHealthCheck{
+			&ExecHealthCheck{Command: "/bin/sh", Args: []string{"-c", "exit 1"}, Timeout: "1s", WarningIsDegraded: true},
+		},
+	}
+	backend.runHealthChecks(scrapeSettings{timeout: 2 * time.Second})
+	assert.True(t, backend.Alive)
+	assert.True(t, backend.Degraded)
+
+	backend.HealthChecks[0].(*ExecHealthCheck).Args = []string{"-c", "exit 0"}
+	backend.runHealthChecks(scrapeSettings{timeout: 2 * time.Second})
+	assert.True(t, backend.Alive)
+	assert.False(t, backend.Degraded)
+}
+
+func TestExecHealthCheck_Equals(t *testing.T) {
+	hc1 := &ExecHealthCheck{Command: "/usr/lib/nagios/check_http", Args: []string{"-H", "example.com"}, Timeout: "5s"}
+	hc2 := &ExecHealthCheck{Command: "/usr/lib/nagios/check_http", Args: []string{"-H", "example.com"}, Timeout: "5s"}
+	hc3 := &ExecHealthCheck{Command: "/usr/lib/nagios/check_http", Args: []string{"-H", "example.org"}, Timeout: "5s"}
+
+	assert.True(t, hc1.Equals(hc2))
+	assert.False(t, hc1.Equals(hc3))
+	assert.False(t, hc1.Equals(&TCPHealthCheck{}))
+}
@@ -1,8 +1,20 @@
 package gslb
 
 import (
+	"context"
+	"net"
+	"strconv"
 	"testing"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 func TestGRPCHealthCheck_Check(t *testing.T) {
@@ -18,3 +30,107 @@ func TestGRPCHealthCheck_Check(t *testing.T) {
 		t.Error("expected error when no gRPC server is running")
 	}
 }
+
+func TestGRPCHealthCheck_TransportCredentials(t *testing.T) {
+	hc := &GRPCHealthCheck{Host: "127.0.0.1"}
+	creds, err := hc.transportCredentials(hc.Host)
+	if err != nil || creds.Info().SecurityProtocol != "insecure" {
+		t.Errorf("expected insecure credentials, got %v (err=%v)", creds, err)
+	}
+
+	hc.EnableTLS = true
+	hc.ServerName = "grpc.example.com"
+	creds, err = hc.transportCredentials(hc.Host)
+	if err != nil || creds.Info().SecurityProtocol != "tls" || creds.Info().ServerName != "grpc.example.com" {
+		t.Errorf("expected TLS credentials for grpc.example.com, got %+v (err=%v)", creds.Info(), err)
+	}
+
+	hc.CAFile = "/nonexistent/ca.pem"
+	if _, err := hc.transportCredentials(hc.Host); err == nil {
+		t.Error("expected error with a missing CA file")
+	}
+}
+
+// newGRPCTestServer starts a gRPC server with the standard health service.
+// When token is set, calls without the matching authorization metadata are rejected.
+func newGRPCTestServer(t *testing.T, token string) (*health.Server, int) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	authorize := func(ctx context.Context) error {
+		if token == "" {
+			return nil
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+		if values := md.Get("authorization"); len(values) == 0 || values[0] != "Bearer "+token {
+			return status.Error(codes.Unauthenticated, "missing token")
+		}
+		return nil
+	}
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if err := authorize(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := authorize(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	)
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	go server.Serve(ln)
+	t.Cleanup(server.Stop)
+	return healthServer, ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestGRPCHealthCheck_PerformCheck(t *testing.T) {
+	RegisterMetrics()
+	healthServer, port := newGRPCTestServer(t, "secret")
+	healthServer.SetServingStatus("app", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus("batch", healthpb.HealthCheckResponse_NOT_SERVING)
+	backend := &Backend{Address: "127.0.0.1"}
+
+	hc := &GRPCHealthCheck{Port: port, Service: "app", Timeout: time.Second, Metadata: map[string]string{"authorization": "Bearer secret"}}
+	before := testutil.ToFloat64(healthcheckTotal.WithLabelValues("grpc.example.com.", "grpc/"+strconv.Itoa(port), "127.0.0.1", "success"))
+	assert.True(t, hc.PerformCheck(backend, "grpc.example.com.", 0))
+	after := testutil.ToFloat64(healthcheckTotal.WithLabelValues("grpc.example.com.", "grpc/"+strconv.Itoa(port), "127.0.0.1", "success"))
+	assert.Equal(t, before+1, after)
+
+	notServing := &GRPCHealthCheck{Port: port, Service: "batch", Timeout: time.Second, Metadata: hc.Metadata}
+	assert.False(t, notServing.PerformCheck(backend, "grpc.example.com.", 1))
+
+	noToken := &GRPCHealthCheck{Port: port, Service: "app", Timeout: time.Second}
+	assert.False(t, noToken.PerformCheck(backend, "grpc.example.com.", 0))
+}
+
+func TestGRPCHealthCheck_Watch(t *testing.T) {
+	healthServer, port := newGRPCTestServer(t, "")
+	healthServer.SetServingStatus("app", healthpb.HealthCheckResponse_SERVING)
+	backend := &Backend{Address: "127.0.0.1"}
+
+	hc := &GRPCHealthCheck{Port: port, Service: "app", Timeout: time.Second, Watch: true}
+	assert.True(t, hc.PerformCheck(backend, "grpc.example.com.", 0))
+
+	// The new status is pushed on the open stream
+	healthServer.SetServingStatus("app", healthpb.HealthCheckResponse_NOT_SERVING)
+	assert.Eventually(t, func() bool {
+		return !hc.PerformCheck(backend, "grpc.example.com.", 0)
+	}, 2*time.Second, 50*time.Millisecond)
+
+	// Retries do not wait on the same status
+	start := time.Now()
+	assert.False(t, hc.PerformCheck(backend, "grpc.example.com.", 3))
+	assert.Less(t, time.Since(start), hc.Timeout)
+
+	healthServer.SetServingStatus("app", healthpb.HealthCheckResponse_SERVING)
+	assert.Eventually(t, func() bool {
+		return hc.PerformCheck(backend, "grpc.example.com.", 0)
+	}, 2*time.Second, 50*time.Millisecond)
+	assert.Len(t, hc.watchers, 1)
+}
@@ -0,0 +1,86 @@
+package gslb
+
+import (
+	"time"
+)
+
+// checkHistorySize is the number of results kept for each health check of a backend.
+const checkHistorySize = 20
+
+// CheckResult is the outcome of one run of a health check.
+type CheckResult struct {
+	Time       time.Time `json:"time"`
+	DurationMs float64   `json:"duration_ms"`
+	Success    bool      `json:"success"`
+	Reason     string    `json:"reason,omitempty"` // Failure reason, as reported by gslb_healthcheck_failures_total
+	Error      string    `json:"error,omitempty"`
+}
+
+// checkHistory is a bounded ring buffer of the last results of a health check.
+type checkHistory struct {
+	results [checkHistorySize]CheckResult
+	next    int
+	count   int
+}
+
+// add stores a result, overwriting the oldest one when the buffer is full.
+func (h *checkHistory) add(result CheckResult) {
+	h.results[h.next] = result
+	h.next = (h.next + 1) % checkHistorySize
+	if h.count < checkHistorySize {
+		h.count++
+	}
+}
+
+// list returns the stored results, most recent first.
+func (h *checkHistory) list() []CheckResult {
+	results := make([]CheckResult, 0, h.count)
+	for i := 1; i <= h.count; i++ {
+		results = append(results, h.results[(h.next-i+checkHistorySize)%checkHistorySize])
+	}
+	return results
+}
+
+// CheckHistory is the result history of one health check of a backend.
+type CheckHistory struct {
+	Type    string        `json:"type"`
+	Results []CheckResult `json:"results"`
+}
+
+// ReportFailure counts a failed health check and records its reason and error
+// for the result history. It is called once per run, after the last retry.
+func (b *Backend) ReportFailure(checkType, reason string, err error) {
+	IncHealthcheckFailures(checkType, b.Address, reason)
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.failureReason = reason
+	b.failureError = ""
+	if err != nil {
+		b.failureError = redactSecrets(err.Error())
+	}
+}
+
+// recordCheckResult appends a result to the history of the health check at index i.
+func (b *Backend) recordCheckResult(i int, result CheckResult) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if len(b.history) != len(b.HealthChecks) {
+		b.history = make([]checkHistory, len(b.HealthChecks))
+	}
+	b.history[i].add(result)
+}
+
+// GetCheckHistory returns the result history of each health check of the backend.
+func (b *Backend) GetCheckHistory() []CheckHistory {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	histories := make([]CheckHistory, 0, len(b.HealthChecks))
+	for i, hc := range b.HealthChecks {
+		history := CheckHistory{Type: hc.GetType(), Results: []CheckResult{}}
+		if i < len(b.history) {
+			history.Results = b.history[i].list()
+		}
+		histories = append(histories, history)
+	}
+	return histories
+}
@@ -0,0 +1,63 @@
+package gslb
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckHistory_Ring(t *testing.T) {
+	var history checkHistory
+	assert.Empty(t, history.list())
+
+	for i := 0; i < checkHistorySize+5; i++ {
+		history.add(CheckResult{Error: fmt.Sprintf("run %d", i)})
+	}
+	results := history.list()
+	assert.Len(t, results, checkHistorySize)
+	assert.Equal(t, fmt.Sprintf("run %d", checkHistorySize+4), results[0].Error)
+	assert.Equal(t, "run 5", results[checkHistorySize-1].Error)
+}
+
+func TestBackend_CheckHistory(t *testing.T) {
+	RegisterMetrics()
+	push := &PushHealthCheck{TTL: "1m"}
+	backend := &Backend{Fqdn: "worker.example.com.", Address: "10.0.0.1", Enable: true, HealthChecks: []GenericHealthCheck{push}}
+
+	backend.runHealthChecks(scrapeSettings{timeout: time.Second})
+	_, err := push.Report(true, "", 0)
+	assert.NoError(t, err)
+	backend.runHealthChecks(scrapeSettings{timeout: time.Second})
+
+	histories := backend.GetCheckHistory()
+	assert.Len(t, histories, 1)
+	assert.Equal(t, "push", histories[0].Type)
+	results := histories[0].Results
+	assert.Len(t, results, 2)
+	assert.True(t, results[0].Success)
+	assert.Empty(t, results[0].Reason)
+	assert.Empty(t, results[0].Error)
+	assert.False(t, results[1].Success)
+	assert.Equal(t, "other", results[1].Reason)
+	assert.Equal(t, "no report received", results[1].Error)
+	assert.False(t, results[1].Time.IsZero())
+
+	// The history is reset when the health checks change
+	backend.updateBackend(&Backend{Address: "10.0.0.1", HealthChecks: []GenericHealthCheck{&PushHealthCheck{TTL: "2m"}}})
+	assert.Empty(t, backend.GetCheckHistory()[0].Results)
+}
+
+func TestBackend_CheckHistoryTimeout(t *testing.T) {
+	RegisterMetrics()
+	slow := &ExecHealthCheck{Command: "/bin/sh", Args: []string{"-c", "sleep 1"}, Timeout: "2s"}
+	backend := &Backend{Fqdn: "app.example.com.", Address: "10.0.0.1", HealthChecks: []GenericHealthCheck{slow}}
+	backend.runHealthChecks(scrapeSettings{timeout: 20 * time.Millisecond})
+
+	results := backend.GetCheckHistory()[0].Results
+	assert.Len(t, results, 1)
+	assert.False(t, results[0].Success)
+	assert.Equal(t, "timeout", results[0].Reason)
+	assert.Equal(t, float64(20), results[0].DurationMs)
+}
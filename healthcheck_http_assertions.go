@@ -0,0 +1,248 @@
+package gslb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// HTTPAssertion is a single check applied to an HTTP healthcheck response,
+// either on a JSON body value selected by a JSONPath or on a response header.
+type HTTPAssertion struct {
+	Name     string `yaml:"name"`     // Label used in logs (default: the assertion itself)
+	JSONPath string `yaml:"jsonpath"` // JSONPath of the body value, e.g. $.status or $.items[0].name
+	Header   string `yaml:"header"`   // Response header name
+	Operator string `yaml:"operator"` // ==, !=, <, <=, >, >=, contains, matches or exists
+	Value    string `yaml:"value"`    // Value to compare with
+}
+
+// String returns the assertion name, or a readable form of the assertion.
+func (a HTTPAssertion) String() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	subject := a.JSONPath
+	if a.Header != "" {
+		subject = "header " + a.Header
+	}
+	if a.Operator == "exists" {
+		return subject + " exists"
+	}
+	return fmt.Sprintf("%s %s %q", subject, a.Operator, a.Value)
+}
+
+// validate checks that the assertion targets exactly one subject with a known operator.
+func (a HTTPAssertion) validate() error {
+	if (a.JSONPath == "") == (a.Header == "") {
+		return fmt.Errorf("assertion '%s' needs either jsonpath or header", a)
+	}
+	switch a.Operator {
+	case "==", "!=", "<", "<=", ">", ">=", "contains", "exists":
+	case "matches":
+		if _, err := regexp.Compile(a.Value); err != nil {
+			return fmt.Errorf("assertion '%s' has an invalid regex: %w", a, err)
+		}
+	default:
+		return fmt.Errorf("assertion '%s' has an unknown operator '%s'", a, a.Operator)
+	}
+	if a.JSONPath != "" {
+		if _, err := parseJSONPath(a.JSONPath); err != nil {
+			return fmt.Errorf("assertion '%s': %w", a, err)
+		}
+	}
+	return nil
+}
+
+// needsBody reports whether the assertion is evaluated against the JSON body.
+func (a HTTPAssertion) needsBody() bool {
+	return a.JSONPath != ""
+}
+
+// Evaluate applies the assertion to the response headers and the decoded JSON body.
+func (a HTTPAssertion) Evaluate(header http.Header, body interface{}) error {
+	if a.Header != "" {
+		values, found := header[http.CanonicalHeaderKey(a.Header)]
+		if !found {
+			return fmt.Errorf("header %s is missing", a.Header)
+		}
+		if a.Operator == "exists" {
+			return nil
+		}
+		return compareValue(strings.Join(values, ", "), a.Operator, a.Value)
+	}
+
+	value, found := lookupJSONPath(body, a.JSONPath)
+	if !found {
+		return fmt.Errorf("%s not found in response body", a.JSONPath)
+	}
+	if a.Operator == "exists" {
+		return nil
+	}
+	return compareValue(value, a.Operator, a.Value)
+}
+
+// compareValue compares a JSON or header value with the expected one.
+// Numeric operators require both sides to be numbers, equality is numeric
+// when both sides are numbers and textual otherwise.
+func compareValue(actual interface{}, operator, expected string) error {
+	switch operator {
+	case "contains":
+		if items, ok := actual.([]interface{}); ok {
+			for _, item := range items {
+				if formatJSONValue(item) == expected {
+					return nil
+				}
+			}
+			return fmt.Errorf("%s does not contain %q", formatJSONValue(actual), expected)
+		}
+		if !strings.Contains(formatJSONValue(actual), expected) {
+			return fmt.Errorf("%q does not contain %q", formatJSONValue(actual), expected)
+		}
+		return nil
+	case "matches":
+		re := regexp.MustCompile(expected)
+		if !re.MatchString(formatJSONValue(actual)) {
+			return fmt.Errorf("%q does not match %q", formatJSONValue(actual), expected)
+		}
+		return nil
+	}
+
+	actualNumber, actualIsNumber := toNumber(actual)
+	expectedNumber, err := strconv.ParseFloat(expected, 64)
+	expectedIsNumber := err == nil
+
+	switch operator {
+	case "==", "!=":
+		equal := formatJSONValue(actual) == expected
+		if actualIsNumber && expectedIsNumber {
+			equal = actualNumber == expectedNumber
+		}
+		if equal != (operator == "==") {
+			return fmt.Errorf("got %s", formatJSONValue(actual))
+		}
+		return nil
+	}
+
+	if !actualIsNumber || !expectedIsNumber {
+		return fmt.Errorf("cannot compare %s with %q using %s", formatJSONValue(actual), expected, operator)
+	}
+	var ok bool
+	switch operator {
+	case "<":
+		ok = actualNumber < expectedNumber
+	case "<=":
+		ok = actualNumber <= expectedNumber
+	case ">":
+		ok = actualNumber > expectedNumber
+	case ">=":
+		ok = actualNumber >= expectedNumber
+	}
+	if !ok {
+		return fmt.Errorf("got %s", formatJSONValue(actual))
+	}
+	return nil
+}
+
+// toNumber converts a JSON number or a numeric string to a float.
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// formatJSONValue renders strings as is and any other value as JSON.
+func formatJSONValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// parseJSONPath splits a JSONPath such as $.a.b[0]['c d'] into keys (string) and indexes (int).
+// Only the child and index selectors are supported.
+func parseJSONPath(path string) ([]interface{}, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("jsonpath '%s' must start with $", path)
+	}
+	var steps []interface{}
+	rest := path[1:]
+	for rest != "" {
+		switch {
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end == -1 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, fmt.Errorf("jsonpath '%s' has an empty key", path)
+			}
+			steps = append(steps, key)
+			rest = rest[end+1:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("jsonpath '%s' has an unterminated [", path)
+			}
+			selector := rest[1:end]
+			if len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0] {
+				steps = append(steps, selector[1:len(selector)-1])
+			} else if index, err := strconv.Atoi(selector); err == nil {
+				steps = append(steps, index)
+			} else {
+				return nil, fmt.Errorf("jsonpath '%s' has an unsupported selector [%s]", path, selector)
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("jsonpath '%s' is invalid near '%s'", path, rest)
+		}
+	}
+	return steps, nil
+}
+
+// lookupJSONPath returns the value selected by path in a document decoded with UseNumber.
+func lookupJSONPath(document interface{}, path string) (interface{}, bool) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, false
+	}
+	current := document
+	for _, step := range steps {
+		switch s := step.(type) {
+		case string:
+			object, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if current, ok = object[s]; !ok {
+				return nil, false
+			}
+		case int:
+			array, ok := current.([]interface{})
+			if !ok {
+				return nil, false
+			}
+			if s < 0 {
+				s += len(array)
+			}
+			if s < 0 || s >= len(array) {
+				return nil, false
+			}
+			current = array[s]
+		}
+	}
+	return current, true
+}
@@ -0,0 +1,76 @@
+package gslb
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeTestJSON(t *testing.T, data string) interface{} {
+	var document interface{}
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		t.Fatalf("failed to decode JSON: %v", err)
+	}
+	return document
+}
+
+func TestParseJSONPath(t *testing.T) {
+	steps, err := parseJSONPath("$.items[0]['display name'].value")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"items", 0, "display name", "value"}, steps)
+
+	steps, err = parseJSONPath("$")
+	assert.NoError(t, err)
+	assert.Empty(t, steps)
+
+	for _, path := range []string{"status", "$..status", "$.items[", "$.items[*]"} {
+		_, err := parseJSONPath(path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestHTTPAssertion_Evaluate(t *testing.T) {
+	body := decodeTestJSON(t, `{"status":"UP","queue_depth":42,"ready":true,"regions":["eu","us"],"checks":[{"name":"db","ok":true}]}`)
+	header := http.Header{"X-Version": []string{"1.4.2"}}
+
+	tests := []struct {
+		assertion HTTPAssertion
+		ok        bool
+	}{
+		{HTTPAssertion{JSONPath: "$.status", Operator: "==", Value: "UP"}, true},
+		{HTTPAssertion{JSONPath: "$.status", Operator: "!=", Value: "UP"}, false},
+		{HTTPAssertion{JSONPath: "$.queue_depth", Operator: "<", Value: "1000"}, true},
+		{HTTPAssertion{JSONPath: "$.queue_depth", Operator: ">=", Value: "100"}, false},
+		{HTTPAssertion{JSONPath: "$.queue_depth", Operator: "==", Value: "42.0"}, true},
+		{HTTPAssertion{JSONPath: "$.status", Operator: ">", Value: "1"}, false},
+		{HTTPAssertion{JSONPath: "$.ready", Operator: "==", Value: "true"}, true},
+		{HTTPAssertion{JSONPath: "$.regions", Operator: "contains", Value: "eu"}, true},
+		{HTTPAssertion{JSONPath: "$.regions", Operator: "contains", Value: "ap"}, false},
+		{HTTPAssertion{JSONPath: "$.checks[0].name", Operator: "==", Value: "db"}, true},
+		{HTTPAssertion{JSONPath: "$.checks[-1].ok", Operator: "exists"}, true},
+		{HTTPAssertion{JSONPath: "$.missing", Operator: "exists"}, false},
+		{HTTPAssertion{Header: "x-version", Operator: "matches", Value: `^1\.4\.`}, true},
+		{HTTPAssertion{Header: "X-Version", Operator: "contains", Value: "2.0"}, false},
+		{HTTPAssertion{Header: "X-Missing", Operator: "exists"}, false},
+	}
+	for _, test := range tests {
+		assert.NoError(t, test.assertion.validate(), test.assertion.String())
+		err := test.assertion.Evaluate(header, body)
+		assert.Equal(t, test.ok, err == nil, "%s: %v", test.assertion, err)
+	}
+}
+
+func TestHTTPAssertion_Validate(t *testing.T) {
+	assert.Error(t, HTTPAssertion{Operator: "=="}.validate())
+	assert.Error(t, HTTPAssertion{JSONPath: "$.a", Header: "X-A", Operator: "=="}.validate())
+	assert.Error(t, HTTPAssertion{JSONPath: "$.a", Operator: "~="}.validate())
+	assert.Error(t, HTTPAssertion{Header: "X-A", Operator: "matches", Value: "("}.validate())
+
+	assert.Equal(t, "queue", HTTPAssertion{Name: "queue", JSONPath: "$.q", Operator: "<", Value: "5"}.String())
+	assert.Equal(t, `$.q < "5"`, HTTPAssertion{JSONPath: "$.q", Operator: "<", Value: "5"}.String())
+}
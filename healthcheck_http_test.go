@@ -1,12 +1,21 @@
 package gslb
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/quic-go/quic-go/http3"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 // Test HTTPHealthCheck
@@ -149,3 +158,191 @@ func TestHTTPHealthCheck_Equals(t *testing.T) {
 	// Assert that hc1 and hc3 are not equal
 	assert.False(t, hc1.Equals(hc3))
 }
+
+func TestHTTPHealthCheck_MutualTLS(t *testing.T) {
+	certFile, keyFile, clientCert := writeClientCertificate(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600); err != nil {
+		t.Fatalf("failed to write CA file: %v", err)
+	}
+
+	backend := &Backend{Address: "127.0.0.1"}
+	newCheck := func() *HTTPHealthCheck {
+		return &HTTPHealthCheck{
+			Port:          server.Listener.Addr().(*net.TCPAddr).Port,
+			EnableTLS:     true,
+			URI:           "/",
+			Method:        "GET",
+			Host:          "app.internal",
+			Timeout:       "2s",
+			ExpectedCode:  200,
+			ClientCert:    certFile,
+			ClientKey:     keyFile,
+			CAFile:        caFile,
+			ServerName:    "example.com",
+			MinTLSVersion: "1.2",
+		}
+	}
+
+	assert.True(t, newCheck().PerformCheck(backend, "example.com", 0))
+
+	noClientCert := newCheck()
+	noClientCert.ClientCert, noClientCert.ClientKey = "", ""
+	assert.False(t, noClientCert.PerformCheck(backend, "example.com", 0))
+
+	wrongSNI := newCheck()
+	wrongSNI.ServerName = "wrong.example.org"
+	assert.False(t, wrongSNI.PerformCheck(backend, "example.com", 0))
+
+	invalidVersion := newCheck()
+	invalidVersion.MinTLSVersion = "2.0"
+	assert.False(t, invalidVersion.PerformCheck(backend, "example.com", 0))
+}
+
+func TestHTTPHealthCheck_Protocol(t *testing.T) {
+	backend := &Backend{Address: "127.0.0.1"}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	})
+	newCheck := func(port int, protocol string, enableTLS bool) *HTTPHealthCheck {
+		return &HTTPHealthCheck{
+			Port:          port,
+			EnableTLS:     enableTLS,
+			SkipTLSVerify: true,
+			URI:           "/",
+			Method:        "GET",
+			Timeout:       "2s",
+			ExpectedCode:  200,
+			Protocol:      protocol,
+		}
+	}
+
+	t.Run("H2", func(t *testing.T) {
+		server := httptest.NewUnstartedServer(handler)
+		server.EnableHTTP2 = true
+		server.StartTLS()
+		defer server.Close()
+		port := server.Listener.Addr().(*net.TCPAddr).Port
+
+		assert.True(t, newCheck(port, "h2", true).PerformCheck(backend, "example.com", 0))
+		assert.True(t, newCheck(port, "http1", true).PerformCheck(backend, "example.com", 0))
+	})
+
+	t.Run("H2NotNegotiated", func(t *testing.T) {
+		server := httptest.NewTLSServer(handler)
+		defer server.Close()
+		port := server.Listener.Addr().(*net.TCPAddr).Port
+
+		assert.False(t, newCheck(port, "h2", true).PerformCheck(backend, "example.com", 0))
+	})
+
+	t.Run("H2C", func(t *testing.T) {
+		server := httptest.NewServer(h2c.NewHandler(handler, &http2.Server{}))
+		defer server.Close()
+		port := server.Listener.Addr().(*net.TCPAddr).Port
+
+		assert.True(t, newCheck(port, "h2c", false).PerformCheck(backend, "example.com", 0))
+	})
+
+	t.Run("H3", func(t *testing.T) {
+		tlsServer := httptest.NewTLSServer(handler)
+		defer tlsServer.Close()
+
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen on UDP: %v", err)
+		}
+		server := &http3.Server{
+			Handler:   handler,
+			TLSConfig: http3.ConfigureTLSConfig(&tls.Config{Certificates: tlsServer.TLS.Certificates}),
+		}
+		go server.Serve(conn)
+		defer server.Close()
+		port := conn.LocalAddr().(*net.UDPAddr).Port
+
+		assert.True(t, newCheck(port, "h3", true).PerformCheck(backend, "example.com", 0))
+
+		// The UDP socket of each probe is released
+		fds, err := os.ReadDir("/proc/self/fd")
+		if err != nil {
+			t.Skip("open file descriptors cannot be counted")
+		}
+		for i := 0; i < 10; i++ {
+			assert.True(t, newCheck(port, "h3", true).PerformCheck(backend, "example.com", 0))
+		}
+		after, err := os.ReadDir("/proc/self/fd")
+		assert.NoError(t, err)
+		assert.Less(t, len(after), len(fds)+5)
+	})
+
+	t.Run("InvalidCombination", func(t *testing.T) {
+		assert.False(t, newCheck(80, "h3", false).PerformCheck(backend, "example.com", 0))
+		assert.False(t, newCheck(443, "h2c", true).PerformCheck(backend, "example.com", 0))
+		assert.False(t, newCheck(80, "spdy", false).PerformCheck(backend, "example.com", 0))
+	})
+
+	t.Run("Type", func(t *testing.T) {
+		assert.Equal(t, "h3/443", newCheck(443, "h3", true).GetType())
+		assert.Equal(t, "h2c/80", newCheck(80, "h2c", false).GetType())
+		assert.Equal(t, "https/443", newCheck(443, "http1", true).GetType())
+	})
+}
+
+func TestHTTPHealthCheck_Assertions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			body, _ := io.ReadAll(r.Body)
+			if string(body) != `{"probe":true}` {
+				w.WriteHeader(400)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		w.Write([]byte(`{"status":"UP","queue_depth":12}`))
+	}))
+	defer server.Close()
+
+	backend := &Backend{Address: "127.0.0.1"}
+	newCheck := func(assertions ...HTTPAssertion) *HTTPHealthCheck {
+		return &HTTPHealthCheck{
+			Port:         server.Listener.Addr().(*net.TCPAddr).Port,
+			URI:          "/health",
+			Method:       "GET",
+			Timeout:      "2s",
+			ExpectedCode: 200,
+			Assertions:   assertions,
+		}
+	}
+
+	assert.True(t, newCheck(
+		HTTPAssertion{JSONPath: "$.status", Operator: "==", Value: "UP"},
+		HTTPAssertion{JSONPath: "$.queue_depth", Operator: "<", Value: "1000"},
+		HTTPAssertion{Header: "Content-Type", Operator: "contains", Value: "json"},
+	).PerformCheck(backend, "example.com", 0))
+
+	assert.False(t, newCheck(HTTPAssertion{JSONPath: "$.queue_depth", Operator: "<", Value: "10"}).PerformCheck(backend, "example.com", 0))
+	assert.False(t, newCheck(HTTPAssertion{Header: "X-Request-Id", Operator: "exists"}).PerformCheck(backend, "example.com", 0))
+	assert.False(t, newCheck(HTTPAssertion{JSONPath: "status", Operator: "=="}).PerformCheck(backend, "example.com", 0))
+
+	post := newCheck(HTTPAssertion{JSONPath: "$.status", Operator: "==", Value: "UP"})
+	post.Method = "POST"
+	post.Body = `{"probe":true}`
+	assert.True(t, post.PerformCheck(backend, "example.com", 1))
+
+	slow := newCheck()
+	slow.MaxResponseTime = "1ns"
+	assert.False(t, slow.PerformCheck(backend, "example.com", 0))
+	slow.MaxResponseTime = "1s"
+	assert.True(t, slow.PerformCheck(backend, "example.com", 0))
+}
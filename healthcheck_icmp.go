@@ -1,6 +1,7 @@
 package gslb
 
 import (
+	"errors"
 	"time"
 
 	"github.com/creasty/defaults"
@@ -11,6 +12,9 @@ import (
 type ICMPHealthCheck struct {
 	Count   int    `yaml:"count" default:"3"`    // Number of ICMP packets to send
 	Timeout string `yaml:"timeout" default:"5s"` // Maximum duration for pings
+
+	// Source address, interface and mark of the pings
+	SourceBinding `yaml:",inline"`
 }
 
 // SetDefault applies default values to ICMPHealthCheck fields.
@@ -36,16 +40,23 @@ func (h *ICMPHealthCheck) PerformCheck(backend *Backend, fqdn string, maxRetries
 	timeout, err := time.ParseDuration(h.Timeout)
 	if err != nil {
 		log.Errorf("[%s] invalid timeout format: %v", fqdn, err)
-		IncHealthcheckFailures(typeStr, address, "timeout")
+		backend.ReportFailure(typeStr, "timeout", err)
+		return false
+	}
+
+	source := h.SourceBinding
+	if err := source.validate(); err != nil {
+		log.Errorf("[%s] invalid ICMP healthcheck: %v", fqdn, err)
+		backend.ReportFailure(typeStr, "other", err)
 		return false
 	}
 
 	for retry := 0; retry <= maxRetries; retry++ {
-		pinger, err := createPinger(backend.Address, h.Count, timeout)
+		pinger, err := createPinger(backend.Address, h.Count, timeout, source)
 		if err != nil {
 			log.Errorf("[%s] ICMP health check failed to initialize pinger: %v", fqdn, err)
 			if retry == maxRetries {
-				IncHealthcheckFailures(typeStr, address, "connection")
+				backend.ReportFailure(typeStr, "connection", err)
 				return false
 			}
 			continue
@@ -57,7 +68,7 @@ func (h *ICMPHealthCheck) PerformCheck(backend *Backend, fqdn string, maxRetries
 		if err != nil {
 			log.Debugf("[%s] ICMP health check failed: %v", fqdn, err)
 			if retry == maxRetries {
-				IncHealthcheckFailures(typeStr, address, "connection")
+				backend.ReportFailure(typeStr, "connection", err)
 				return false
 			}
 			continue
@@ -71,7 +82,7 @@ func (h *ICMPHealthCheck) PerformCheck(backend *Backend, fqdn string, maxRetries
 		}
 	}
 
-	IncHealthcheckFailures(typeStr, address, "other")
+	backend.ReportFailure(typeStr, "other", errors.New("no ICMP reply received"))
 	return false
 }
 
@@ -83,7 +94,8 @@ func (h *ICMPHealthCheck) Equals(other GenericHealthCheck) bool {
 	}
 
 	return h.Count == otherICMP.Count &&
-		h.Timeout == otherICMP.Timeout
+		h.Timeout == otherICMP.Timeout &&
+		h.SourceBinding == otherICMP.SourceBinding
 }
 
 type Pinger interface {
@@ -108,12 +120,15 @@ func (r *RealPinger) SetPrivileged(privileged bool) {
 	r.pinger.SetPrivileged(privileged)
 }
 
-func createPinger(address string, count int, timeout time.Duration) (Pinger, error) {
+func createPinger(address string, count int, timeout time.Duration, source SourceBinding) (Pinger, error) {
 	pinger, err := probing.NewPinger(address)
 	if err != nil {
 		return nil, err
 	}
 	pinger.Count = count
 	pinger.Timeout = timeout
+	pinger.Source = source.SourceAddress
+	pinger.InterfaceName = source.Interface
+	pinger.SetMark(uint(source.Mark))
 	return &RealPinger{pinger: pinger}, nil
 }
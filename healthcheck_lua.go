@@ -2,26 +2,59 @@ package gslb
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"crypto/tls"
 
 	"github.com/melbahja/goph"
+	"github.com/miekg/dns"
 	gopherlua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
 	ssh "golang.org/x/crypto/ssh"
 )
 
 type LuaHealthCheck struct {
-	Script  string        `yaml:"script"`
-	Timeout time.Duration `yaml:"timeout"`
+	Script     string        `yaml:"script"`
+	ScriptFile string        `yaml:"script_file"` // Read once when the configuration is loaded, instead of script
+	Timeout    time.Duration `yaml:"timeout"`
+
+	mutex  sync.Mutex
+	proto  *gopherlua.FunctionProto // Compiled script, shared by all states
+	digest [sha256.Size]byte        // Digest of the compiled script, the content of script_file included
+	states chan *gopherlua.LState   // Idle sandboxed states
+}
+
+// luaStatePoolSize is the number of idle Lua states kept per healthcheck.
+const luaStatePoolSize = 4
+
+// luaAllowedLibs are the standard libraries available to scripts, os, io, package and debug are not.
+var luaAllowedLibs = []struct {
+	name string
+	open gopherlua.LGFunction
+}{
+	{gopherlua.BaseLibName, gopherlua.OpenBase},
+	{gopherlua.TabLibName, gopherlua.OpenTable},
+	{gopherlua.StringLibName, gopherlua.OpenString},
+	{gopherlua.MathLibName, gopherlua.OpenMath},
+}
+
+// luaRemovedBaseFuncs are the functions of the base library which can reach the filesystem, escape the sandbox,
+// reach the shared metatables or write to the CoreDNS output.
+var luaRemovedBaseFuncs = []string{
+	"dofile", "loadfile", "load", "loadstring", "module", "require", "getfenv", "setfenv", "_printregs",
+	"getmetatable", "setmetatable", "rawget", "rawset", "print",
 }
 
 func (l *LuaHealthCheck) SetDefault() {
@@ -40,70 +73,270 @@ func (l *LuaHealthCheck) Equals(other GenericHealthCheck) bool {
 	if !ok {
 		return false
 	}
-	return l.Script == otherL.Script && l.Timeout == otherL.Timeout
+	// The content of script_file is compared as well, so that an edited script is reloaded
+	return l.Script == otherL.Script && l.ScriptFile == otherL.ScriptFile && l.Timeout == otherL.Timeout &&
+		l.scriptDigest() == otherL.scriptDigest()
 }
 
-func (l *LuaHealthCheck) PerformCheck(backend *Backend, fqdn string, maxRetries int) bool {
-	l.SetDefault()
-	typeStr := l.GetType()
-	address := backend.Address
-	start := time.Now()
-	result := false
-	defer func() {
-		ObserveHealthcheck(fqdn, typeStr, address, start, result)
-	}()
+// scriptDigest returns the digest of the compiled script, zero before it is compiled.
+func (l *LuaHealthCheck) scriptDigest() [sha256.Size]byte {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.digest
+}
+
+// compile parses and compiles the script once, the result is reused by every probe.
+func (l *LuaHealthCheck) compile() (*gopherlua.FunctionProto, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.proto != nil {
+		return l.proto, nil
+	}
+	script, name := l.Script, "<healthcheck>"
+	if l.ScriptFile != "" {
+		if l.Script != "" {
+			return nil, fmt.Errorf("script and script_file are mutually exclusive")
+		}
+		data, err := os.ReadFile(l.ScriptFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Lua script: %w", err)
+		}
+		script, name = string(data), l.ScriptFile
+	}
+	chunk, err := parse.Parse(strings.NewReader(script), name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Lua script: %w", err)
+	}
+	proto, err := gopherlua.Compile(chunk, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile Lua script: %w", err)
+	}
+	l.proto = proto
+	l.digest = sha256.Sum256([]byte(script))
+	if l.states == nil {
+		l.states = make(chan *gopherlua.LState, luaStatePoolSize)
+	}
+	return proto, nil
+}
 
-	L := gopherlua.NewState()
-	defer L.Close()
+// newLuaSandbox returns a Lua state with only the whitelisted libraries and the helpers.
+func newLuaSandbox() *gopherlua.LState {
+	L := gopherlua.NewState(gopherlua.Options{SkipOpenLibs: true})
+	for _, lib := range luaAllowedLibs {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(gopherlua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	for _, name := range luaRemovedBaseFuncs {
+		L.SetGlobal(name, gopherlua.LNil)
+	}
 
 	// Inject helpers
 	L.SetGlobal("http_get", L.NewFunction(luaHTTPGet))
 	L.SetGlobal("json_decode", L.NewFunction(luaJSONDecode))
 	L.SetGlobal("metric_get", L.NewFunction(luaMetricGet))
 	L.SetGlobal("ssh_exec", L.NewFunction(luaSSHExec))
+	L.SetGlobal("http_request", L.NewFunction(luaHTTPRequest))
+	L.SetGlobal("tcp_send", L.NewFunction(luaTCPSend))
+	L.SetGlobal("tcp_close", L.NewFunction(luaTCPClose))
+	L.SetGlobal("dns_query", L.NewFunction(luaDNSQuery))
+	return L
+}
+
+// getState returns an idle state from the pool, or a new one.
+func (l *LuaHealthCheck) getState() *gopherlua.LState {
+	select {
+	case L := <-l.states:
+		return L
+	default:
+		return newLuaSandbox()
+	}
+}
+
+// putState returns a state to the pool, or closes it when the pool is full.
+func (l *LuaHealthCheck) putState(L *gopherlua.LState) {
+	select {
+	case l.states <- L:
+	default:
+		L.Close()
+	}
+}
+
+// luaRun holds the state of one script execution, used by the per-run helpers.
+type luaRun struct {
+	backend *Backend
+	fqdn    string
+	conns   []net.Conn
+}
+
+// register adds the helpers bound to this run to the script environment.
+func (r *luaRun) register(L *gopherlua.LState, env *gopherlua.LTable) {
+	L.SetField(env, "log", L.NewFunction(r.luaLog))
+	L.SetField(env, "set_detail", L.NewFunction(r.luaSetDetail))
+	L.SetField(env, "tcp_connect", L.NewFunction(r.luaTCPConnect))
+}
+
+// close releases the resources left open by the script.
+func (r *luaRun) close() {
+	for _, conn := range r.conns {
+		conn.Close()
+	}
+}
+
+// newLuaEnv returns the global environment of a run, a copy of the sandbox globals
+// where the library tables are copied as well, so that a script changing them does
+// not affect the next runs of the state.
+func newLuaEnv(L *gopherlua.LState) *gopherlua.LTable {
+	env := L.NewTable()
+	globals := L.Get(gopherlua.GlobalsIndex).(*gopherlua.LTable)
+	globals.ForEach(func(key, value gopherlua.LValue) {
+		if lib, ok := value.(*gopherlua.LTable); ok {
+			if lib == globals {
+				return
+			}
+			libCopy := L.NewTable()
+			lib.ForEach(func(k, v gopherlua.LValue) { libCopy.RawSet(k, v) })
+			value = libCopy
+		}
+		env.RawSet(key, value)
+	})
+	L.SetField(env, "_G", env)
+	return env
+}
+
+// run executes the compiled script in a pooled state. Each run has its own global
+// environment, so that nothing leaks between probes, and is cancelled after Timeout.
+func (l *LuaHealthCheck) run(proto *gopherlua.FunctionProto, backend *Backend, fqdn string) (bool, error) {
+	L := l.getState()
+	ctx, cancel := context.WithTimeout(context.Background(), l.Timeout)
+	defer cancel()
+	L.SetContext(ctx)
+
+	env := newLuaEnv(L)
 
 	// Inject backend table
 	backendTable := L.NewTable()
 	L.SetField(backendTable, "address", gopherlua.LString(backend.Address))
 	L.SetField(backendTable, "priority", gopherlua.LNumber(backend.Priority))
-	L.SetGlobal("backend", backendTable)
+	L.SetField(env, "backend", backendTable)
+
+	run := &luaRun{backend: backend, fqdn: fqdn}
+	run.register(L, env)
+	defer run.close()
 
-	err := L.DoString(l.Script)
+	fn := L.NewFunctionFromProto(proto)
+	fn.Env = env
+	L.Push(fn)
+	err := L.PCall(0, 1, nil)
 	if err != nil {
-		return false
+		// The state may be left inconsistent after an error or a cancellation
+		L.Close()
+		if ctx.Err() != nil {
+			return false, fmt.Errorf("script cancelled after %s: %w", l.Timeout, ctx.Err())
+		}
+		return false, err
 	}
 	ret := L.Get(-1)
+	L.Pop(1)
+	L.RemoveContext()
+	l.putState(L)
+
 	if lv, ok := ret.(gopherlua.LBool); ok {
-		result = bool(lv)
-		return result
+		return bool(lv), nil
+	}
+	return false, fmt.Errorf("script returned %s, expected a boolean", ret.Type())
+}
+
+func (l *LuaHealthCheck) PerformCheck(backend *Backend, fqdn string, maxRetries int) bool {
+	l.SetDefault()
+	typeStr := l.GetType()
+	address := backend.Address
+	start := time.Now()
+	result := false
+	defer func() {
+		ObserveHealthcheck(fqdn, typeStr, address, start, result)
+	}()
+
+	proto, err := l.compile()
+	if err != nil {
+		log.Errorf("[%s] invalid Lua healthcheck: %v", fqdn, err)
+		backend.ReportFailure(typeStr, "other", err)
+		return false
 	}
+
+	for retry := 0; retry <= maxRetries; retry++ {
+		healthy, err := l.run(proto, backend, fqdn)
+		if err != nil || !healthy {
+			log.Debugf("[%s] Lua healthcheck failed (retries=%d/%d): [backend=%s] %v", fqdn, retry, maxRetries, address, err)
+			if retry == maxRetries {
+				reason := "protocol"
+				if errors.Is(err, context.DeadlineExceeded) {
+					reason = "timeout"
+				} else if err != nil {
+					reason = "other"
+				}
+				if err == nil {
+					err = errors.New("script reported unhealthy")
+				}
+				backend.ReportFailure(typeStr, reason, err)
+				return false
+			}
+			continue
+		}
+		log.Debugf("[%s] Lua healthcheck success [backend=%s]", fqdn, address)
+		result = true
+		return true
+	}
+
+	backend.ReportFailure(typeStr, "other", nil)
 	return false
 }
 
-// Helper: json_decode(str) in Lua
+// luaContext returns the context of the running script, so that helpers are cancelled with it.
+func luaContext(l *gopherlua.LState) context.Context {
+	if ctx := l.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+// Helper: json_decode(str) in Lua, objects and arrays are converted recursively
 func luaJSONDecode(l *gopherlua.LState) int {
 	str := l.ToString(1)
-	var data map[string]interface{}
+	var data interface{}
 	err := json.Unmarshal([]byte(str), &data)
 	if err != nil {
 		l.Push(gopherlua.LNil)
 		return 1
 	}
-	tbl := l.NewTable()
-	for k, v := range data {
-		switch val := v.(type) {
-		case string:
-			l.SetField(tbl, k, gopherlua.LString(val))
-		case float64:
-			l.SetField(tbl, k, gopherlua.LNumber(val))
-		case bool:
-			l.SetField(tbl, k, gopherlua.LBool(val))
-		default:
-			l.SetField(tbl, k, gopherlua.LString(fmt.Sprintf("%v", val)))
+	l.Push(luaFromJSON(l, data))
+	return 1
+}
+
+// luaFromJSON converts a decoded JSON value to a Lua value, arrays are 1-indexed and null is nil.
+func luaFromJSON(l *gopherlua.LState, value interface{}) gopherlua.LValue {
+	switch val := value.(type) {
+	case string:
+		return gopherlua.LString(val)
+	case float64:
+		return gopherlua.LNumber(val)
+	case bool:
+		return gopherlua.LBool(val)
+	case map[string]interface{}:
+		tbl := l.NewTable()
+		for k, v := range val {
+			l.SetField(tbl, k, luaFromJSON(l, v))
 		}
+		return tbl
+	case []interface{}:
+		tbl := l.NewTable()
+		for _, v := range val {
+			tbl.Append(luaFromJSON(l, v))
+		}
+		return tbl
+	default:
+		return gopherlua.LNil
 	}
-	l.Push(tbl)
-	return 1
 }
 
 // Helper: prometheus_metric(url, metric_name)
@@ -133,7 +366,7 @@ func luaMetricGet(l *gopherlua.LState) int {
 	} else {
 		client = &http.Client{Timeout: time.Duration(timeout) * time.Second}
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	ctx, cancel := context.WithTimeout(luaContext(l), time.Duration(timeout)*time.Second)
 	defer cancel()
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -190,7 +423,7 @@ func luaHTTPGet(l *gopherlua.LState) int {
 	if argc >= 5 {
 		tlsVerify = l.ToBool(5)
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	ctx, cancel := context.WithTimeout(luaContext(l), time.Duration(timeout)*time.Second)
 	defer cancel()
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -223,8 +456,11 @@ func luaHTTPGet(l *gopherlua.LState) int {
 	return 1
 }
 
-// Helper: ssh_exec(host, user, password, command, [timeout_sec])
+// Helper: ssh_exec(host, user, password, command, [timeout_sec]) or ssh_exec(options)
 func luaSSHExec(l *gopherlua.LState) int {
+	if options, ok := l.Get(1).(*gopherlua.LTable); ok {
+		return luaSSHExecOptions(l, options)
+	}
 	host := l.ToString(1)
 	user := l.ToString(2)
 	password := l.ToString(3)
@@ -259,3 +495,251 @@ func luaSSHExec(l *gopherlua.LState) int {
 func sshInsecureIgnoreHostKey(host string, remote net.Addr, key ssh.PublicKey) error {
 	return nil // Accept all keys (for healthcheck only)
 }
+
+// Helper: ssh_exec({host=, port=22, user=, password=, key_file=, passphrase=, known_hosts=, insecure=false, command=, timeout=5})
+// Returns the output, or nil and an error message. The host key is verified against
+// known_hosts (default ~/.ssh/known_hosts) unless insecure is true.
+func luaSSHExecOptions(l *gopherlua.LState, options *gopherlua.LTable) int {
+	host := luaOptString(options, "host", "")
+	port := luaOptInt(options, "port", 22)
+	timeout := time.Duration(luaOptInt(options, "timeout", 5)) * time.Second
+
+	var auth goph.Auth
+	if keyFile := luaOptString(options, "key_file", ""); keyFile != "" {
+		var err error
+		if auth, err = goph.Key(keyFile, luaOptString(options, "passphrase", "")); err != nil {
+			return luaError(l, err)
+		}
+	} else {
+		auth = goph.Password(luaOptString(options, "password", ""))
+	}
+
+	callback := sshInsecureIgnoreHostKey
+	if !luaOptBool(options, "insecure", false) {
+		var err error
+		if knownHosts := luaOptString(options, "known_hosts", ""); knownHosts != "" {
+			callback, err = goph.KnownHosts(knownHosts)
+		} else {
+			callback, err = goph.DefaultKnownHosts()
+		}
+		if err != nil {
+			return luaError(l, fmt.Errorf("failed to load known_hosts: %w", err))
+		}
+	}
+
+	client, err := goph.NewConn(&goph.Config{
+		User:     luaOptString(options, "user", ""),
+		Addr:     host,
+		Port:     uint(port),
+		Auth:     auth,
+		Timeout:  timeout,
+		Callback: callback,
+	})
+	if err != nil {
+		return luaError(l, err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(luaContext(l), timeout)
+	defer cancel()
+	out, err := client.RunContext(ctx, luaOptString(options, "command", ""))
+	if err != nil {
+		return luaError(l, err)
+	}
+	l.Push(gopherlua.LString(string(out)))
+	return 1
+}
+
+// Helper: http_request({url=, method="GET", headers={}, body="", timeout=10, tls_verify=true, user=, password=})
+// Returns the status code, the body and the response headers, or nil and an error message.
+func luaHTTPRequest(l *gopherlua.LState) int {
+	options := l.CheckTable(1)
+	timeout := time.Duration(luaOptInt(options, "timeout", 10)) * time.Second
+	ctx, cancel := context.WithTimeout(luaContext(l), timeout)
+	defer cancel()
+
+	var body io.Reader
+	if text := luaOptString(options, "body", ""); text != "" {
+		body = strings.NewReader(text)
+	}
+	req, err := http.NewRequestWithContext(ctx, luaOptString(options, "method", "GET"), luaOptString(options, "url", ""), body)
+	if err != nil {
+		return luaError(l, err)
+	}
+	if headers, ok := options.RawGetString("headers").(*gopherlua.LTable); ok {
+		headers.ForEach(func(key, value gopherlua.LValue) {
+			req.Header.Add(key.String(), value.String())
+		})
+	}
+	if user := luaOptString(options, "user", ""); user != "" {
+		req.SetBasicAuth(user, luaOptString(options, "password", ""))
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: !luaOptBool(options, "tls_verify", true)}
+	client, err := createHTTPClient(httpProtocolHTTP1, tlsConfig, timeout, SourceBinding{}, nil)
+	if err != nil {
+		return luaError(l, err)
+	}
+	defer closeHTTPClient(client)
+	resp, err := client.Do(req)
+	if err != nil {
+		return luaError(l, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return luaError(l, err)
+	}
+
+	headers := l.NewTable()
+	for key := range resp.Header {
+		l.SetField(headers, strings.ToLower(key), gopherlua.LString(resp.Header.Get(key)))
+	}
+	l.Push(gopherlua.LNumber(resp.StatusCode))
+	l.Push(gopherlua.LString(string(respBody)))
+	l.Push(headers)
+	return 3
+}
+
+// Helper: tcp_connect(host, port, [timeout_sec]) returns a connection, or nil and an error message.
+// Connections are closed at the end of the script if tcp_close was not called.
+func (r *luaRun) luaTCPConnect(l *gopherlua.LState) int {
+	addressPort := net.JoinHostPort(l.CheckString(1), strconv.Itoa(l.CheckInt(2)))
+	timeout := time.Duration(l.OptInt(3, 5)) * time.Second
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(luaContext(l), "tcp", addressPort)
+	if err != nil {
+		return luaError(l, err)
+	}
+	r.conns = append(r.conns, conn)
+	ud := l.NewUserData()
+	ud.Value = conn
+	l.Push(ud)
+	return 1
+}
+
+// Helper: tcp_send(conn, data, [timeout_sec]) sends data and returns the first chunk of the response,
+// or nil and an error message. An empty data only waits for the response, e.g. a banner.
+func luaTCPSend(l *gopherlua.LState) int {
+	conn, ok := l.CheckUserData(1).Value.(net.Conn)
+	if !ok {
+		l.ArgError(1, "connection expected")
+		return 0
+	}
+	data := l.OptString(2, "")
+	timeout := time.Duration(l.OptInt(3, 5)) * time.Second
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return luaError(l, err)
+	}
+	if data != "" {
+		if _, err := conn.Write([]byte(data)); err != nil {
+			return luaError(l, err)
+		}
+	}
+	buf := make([]byte, maxResponseSize)
+	n, err := conn.Read(buf)
+	if err != nil && n == 0 {
+		return luaError(l, err)
+	}
+	l.Push(gopherlua.LString(string(buf[:n])))
+	return 1
+}
+
+// Helper: tcp_close(conn)
+func luaTCPClose(l *gopherlua.LState) int {
+	if conn, ok := l.CheckUserData(1).Value.(net.Conn); ok {
+		conn.Close()
+	}
+	return 0
+}
+
+// Helper: dns_query(name, [type="A"], [server], [timeout_sec]) returns the answers as a list of strings
+// and the response code, or nil and an error message. The server defaults to the first one of /etc/resolv.conf.
+func luaDNSQuery(l *gopherlua.LState) int {
+	name := dns.Fqdn(l.CheckString(1))
+	qtype, ok := dns.StringToType[strings.ToUpper(l.OptString(2, "A"))]
+	if !ok {
+		l.ArgError(2, "unknown record type")
+		return 0
+	}
+	server := l.OptString(3, "")
+	if server == "" {
+		config, err := dns.ClientConfigFromFile("/etc/resolv.conf")
+		if err != nil || len(config.Servers) == 0 {
+			return luaError(l, fmt.Errorf("no DNS server configured"))
+		}
+		server = net.JoinHostPort(config.Servers[0], config.Port)
+	} else if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(name, qtype)
+	client := &dns.Client{Timeout: time.Duration(l.OptInt(4, 5)) * time.Second}
+	resp, _, err := client.ExchangeContext(luaContext(l), msg, server)
+	if err != nil {
+		return luaError(l, err)
+	}
+
+	answers := l.NewTable()
+	for _, rr := range resp.Answer {
+		// Keep only the data of the record, without the owner name, TTL, class and type
+		answers.Append(gopherlua.LString(strings.TrimSpace(strings.TrimPrefix(rr.String(), rr.Header().String()))))
+	}
+	l.Push(answers)
+	l.Push(gopherlua.LString(dns.RcodeToString[resp.Rcode]))
+	return 2
+}
+
+// Helper: log(message, [level]) writes to the plugin log with the record name, level is debug, info (default), warning or error.
+func (r *luaRun) luaLog(l *gopherlua.LState) int {
+	message := fmt.Sprintf("[%s] lua healthcheck [backend=%s]: %s", r.fqdn, r.backend.Address, l.CheckString(1))
+	switch l.OptString(2, "info") {
+	case "debug":
+		log.Debug(message)
+	case "warning":
+		log.Warning(message)
+	case "error":
+		log.Error(message)
+	default:
+		log.Info(message)
+	}
+	return 0
+}
+
+// Helper: set_detail(text) explains the result of the script, shown by the overview API.
+func (r *luaRun) luaSetDetail(l *gopherlua.LState) int {
+	r.backend.SetCheckDetail("lua", l.CheckString(1))
+	return 0
+}
+
+// luaError pushes nil and the error message, the Lua convention for recoverable errors.
+func luaError(l *gopherlua.LState, err error) int {
+	l.Push(gopherlua.LNil)
+	l.Push(gopherlua.LString(err.Error()))
+	return 2
+}
+
+// luaOptString returns a string field of an options table, or def when it is not set.
+func luaOptString(options *gopherlua.LTable, key, def string) string {
+	if value, ok := options.RawGetString(key).(gopherlua.LString); ok {
+		return string(value)
+	}
+	return def
+}
+
+// luaOptInt returns a number field of an options table, or def when it is not set.
+func luaOptInt(options *gopherlua.LTable, key string, def int) int {
+	if value, ok := options.RawGetString(key).(gopherlua.LNumber); ok {
+		return int(value)
+	}
+	return def
+}
+
+// luaOptBool returns a boolean field of an options table, or def when it is not set.
+func luaOptBool(options *gopherlua.LTable, key string, def bool) bool {
+	if value, ok := options.RawGetString(key).(gopherlua.LBool); ok {
+		return bool(value)
+	}
+	return def
+}
@@ -3,10 +3,16 @@ package gslb
 import (
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/miekg/dns"
 )
 
 func TestLuaHealthCheck_Success(t *testing.T) {
@@ -224,3 +230,312 @@ func TestLuaHealthCheck_MetricGet_Auth(t *testing.T) {
 		t.Errorf("Expected Lua healthcheck to succeed with metric_get and HTTP Basic auth")
 	}
 }
+
+func TestLuaHealthCheck_Sandbox(t *testing.T) {
+	backend := &Backend{Address: "127.0.0.1", Priority: 1, Enable: true}
+	for _, script := range []string{
+		`return os == nil and io == nil and debug == nil and package == nil`,
+		`return dofile == nil and loadfile == nil and require == nil and load == nil`,
+		`return getmetatable == nil and setmetatable == nil and rawget == nil and rawset == nil and print == nil`,
+		`return string.upper("ok") == "OK" and math.max(1, 2) == 2 and table.concat({"a", "b"}) == "ab"`,
+	} {
+		check := &LuaHealthCheck{Script: script, Timeout: 2 * time.Second}
+		if !check.PerformCheck(backend, "test.local.", 0) {
+			t.Errorf("Expected sandboxed script to succeed: %s", script)
+		}
+	}
+
+	check := &LuaHealthCheck{Script: `return os.execute("true") == 0`, Timeout: 2 * time.Second}
+	if check.PerformCheck(backend, "test.local.", 0) {
+		t.Errorf("Expected os library to be unavailable")
+	}
+}
+
+func TestLuaHealthCheck_IsolatedGlobals(t *testing.T) {
+	// Globals set by a run, including through _G, must not be seen by the next one
+	check := &LuaHealthCheck{
+		Script: `
+			if seen ~= nil then return false end
+			seen = true
+			_G.http_get = nil
+			return true
+		`,
+		Timeout: 2 * time.Second,
+	}
+	backend := &Backend{Address: "127.0.0.1", Priority: 1, Enable: true}
+	for i := 0; i < 3; i++ {
+		if !check.PerformCheck(backend, "test.local.", 0) {
+			t.Fatalf("Expected run %d to start with clean globals", i)
+		}
+	}
+	probe := &LuaHealthCheck{Script: `return http_get ~= nil`, Timeout: 2 * time.Second}
+	probe.states = check.states
+	if !probe.PerformCheck(backend, "test.local.", 0) {
+		t.Errorf("Expected helpers to survive in pooled states")
+	}
+}
+
+func TestLuaHealthCheck_IsolatedLibraries(t *testing.T) {
+	// Changes to the libraries by a run must not be seen by the next one
+	check := &LuaHealthCheck{
+		Script: `
+			if string.rep == nil or string.upper("x") ~= "X" or math.max == nil then return false end
+			string.rep = nil
+			string.upper = function() return "hijacked" end
+			math.max = nil
+			table.insert = nil
+			return true
+		`,
+		Timeout: 2 * time.Second,
+	}
+	backend := &Backend{Address: "127.0.0.1", Priority: 1, Enable: true}
+	for i := 0; i < 3; i++ {
+		if !check.PerformCheck(backend, "test.local.", 0) {
+			t.Fatalf("Expected run %d to start with clean libraries", i)
+		}
+	}
+	probe := &LuaHealthCheck{Script: `return ("ab"):rep(2) == "abab" and ("x"):upper() == "X" and table.insert ~= nil`, Timeout: 2 * time.Second}
+	probe.states = check.states
+	if !probe.PerformCheck(backend, "test.local.", 0) {
+		t.Errorf("Expected libraries to be intact in pooled states")
+	}
+}
+
+func TestLuaHealthCheck_Timeout(t *testing.T) {
+	check := &LuaHealthCheck{Script: `while true do end`, Timeout: 200 * time.Millisecond}
+	backend := &Backend{Address: "127.0.0.1", Priority: 1, Enable: true}
+	start := time.Now()
+	if check.PerformCheck(backend, "test.local.", 0) {
+		t.Errorf("Expected endless script to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected script to be cancelled after its timeout, took %s", elapsed)
+	}
+}
+
+func TestLuaHealthCheck_CompileOnce(t *testing.T) {
+	hc := &HealthCheck{Type: "lua", Params: map[string]interface{}{"script": "return true"}}
+	specific, err := hc.ToSpecificHealthCheck()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	check := specific.(*LuaHealthCheck)
+	if check.proto == nil {
+		t.Errorf("Expected script to be compiled when loading the configuration")
+	}
+	proto := check.proto
+	check.PerformCheck(&Backend{Address: "127.0.0.1"}, "test.local.", 0)
+	if check.proto != proto {
+		t.Errorf("Expected compiled script to be reused")
+	}
+
+	hc = &HealthCheck{Type: "lua", Params: map[string]interface{}{"script": "return ("}}
+	if _, err := hc.ToSpecificHealthCheck(); err == nil {
+		t.Errorf("Expected syntax error when loading the configuration")
+	}
+}
+
+func TestLuaHealthCheck_SyntaxErrorRejectsZone(t *testing.T) {
+	zone := writeTempYAML(t, `
+records:
+  app.example.com.:
+    backends:
+      - address: 10.0.0.1
+        healthchecks:
+          - type: lua
+            params:
+              script: "return ("
+`)
+	err := loadConfigFile(&GSLB{}, zone, "example.com.")
+	if err == nil || !strings.Contains(err.Error(), "invalid Lua healthcheck") {
+		t.Errorf("Expected the zone load to fail with the compile error, got %v", err)
+	}
+}
+
+func TestLuaHealthCheck_JSONDecodeNested(t *testing.T) {
+	check := &LuaHealthCheck{
+		Script: `
+			local doc = json_decode('{"cluster":{"name":"eu","nodes":[{"id":1,"up":true},{"id":2,"up":false}]},"tags":["a","b"]}')
+			return doc.cluster.name == "eu" and #doc.cluster.nodes == 2 and doc.cluster.nodes[2].up == false and doc.tags[1] == "a"
+		`,
+		Timeout: 2 * time.Second,
+	}
+	if !check.PerformCheck(&Backend{Address: "127.0.0.1"}, "test.local.", 0) {
+		t.Errorf("Expected nested JSON to be decoded")
+	}
+}
+
+func TestLuaHealthCheck_HTTPRequest(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != "POST" || r.Header.Get("X-Token") != "secret" || string(body) != "ping" {
+			w.WriteHeader(400)
+			return
+		}
+		w.Header().Set("X-Served-By", "node1")
+		w.WriteHeader(201)
+		io.WriteString(w, "pong")
+	}))
+	defer ts.Close()
+
+	check := &LuaHealthCheck{
+		Script: fmt.Sprintf(`
+			local status, body, headers = http_request({url="%s", method="POST", headers={["X-Token"]="secret"}, body="ping"})
+			return status == 201 and body == "pong" and headers["x-served-by"] == "node1"
+		`, ts.URL),
+		Timeout: 2 * time.Second,
+	}
+	if !check.PerformCheck(&Backend{Address: "127.0.0.1"}, "test.local.", 0) {
+		t.Errorf("Expected http_request to return status, body and headers")
+	}
+
+	check = &LuaHealthCheck{
+		Script:  `local status, err = http_request({url="http://127.0.0.1:1/"}) return status == nil and err ~= nil`,
+		Timeout: 2 * time.Second,
+	}
+	if !check.PerformCheck(&Backend{Address: "127.0.0.1"}, "test.local.", 0) {
+		t.Errorf("Expected http_request to return nil and an error")
+	}
+}
+
+func TestLuaHealthCheck_TCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				conn.Write([]byte("+READY\r\n"))
+				buf := make([]byte, 64)
+				n, _ := conn.Read(buf)
+				conn.Write([]byte("+" + strings.ToUpper(string(buf[:n]))))
+			}(conn)
+		}
+	}()
+
+	check := &LuaHealthCheck{
+		Script: fmt.Sprintf(`
+			local conn = tcp_connect(backend.address, %d)
+			local banner = tcp_send(conn, "")
+			local reply = tcp_send(conn, "ping")
+			tcp_close(conn)
+			return banner == "+READY\r\n" and reply == "+PING"
+		`, ln.Addr().(*net.TCPAddr).Port),
+		Timeout: 2 * time.Second,
+	}
+	if !check.PerformCheck(&Backend{Address: "127.0.0.1"}, "test.local.", 0) {
+		t.Errorf("Expected tcp_connect and tcp_send to talk to the server")
+	}
+}
+
+func TestLuaHealthCheck_DNSQuery(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	server := &dns.Server{PacketConn: pc, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		rr, _ := dns.NewRR(r.Question[0].Name + " 60 IN A 192.0.2.10")
+		m.Answer = append(m.Answer, rr)
+		w.WriteMsg(m)
+	})}
+	go server.ActivateAndServe()
+	defer server.Shutdown()
+
+	check := &LuaHealthCheck{
+		Script: fmt.Sprintf(`
+			local answers, rcode = dns_query("app.example.com", "A", "%s")
+			return rcode == "NOERROR" and answers[1] == "192.0.2.10"
+		`, pc.LocalAddr().String()),
+		Timeout: 2 * time.Second,
+	}
+	if !check.PerformCheck(&Backend{Address: "127.0.0.1"}, "test.local.", 0) {
+		t.Errorf("Expected dns_query to return the answers")
+	}
+}
+
+func TestLuaHealthCheck_SetDetailAndScriptFile(t *testing.T) {
+	scriptFile := filepath.Join(t.TempDir(), "check.lua")
+	script := `log("checking " .. backend.address, "debug") set_detail("replication ok") return true`
+	if err := os.WriteFile(scriptFile, []byte(script), 0600); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+
+	backend := &Backend{Address: "127.0.0.1"}
+	check := &LuaHealthCheck{ScriptFile: scriptFile, Timeout: 2 * time.Second}
+	if !check.PerformCheck(backend, "test.local.", 0) {
+		t.Fatalf("Expected script file to be executed")
+	}
+	if detail := backend.GetCheckDetails()["lua"]; detail != "replication ok" {
+		t.Errorf("Expected detail to be set, got %q", detail)
+	}
+
+	missing := &LuaHealthCheck{ScriptFile: "/nonexistent/check.lua", Timeout: 2 * time.Second}
+	if missing.PerformCheck(backend, "test.local.", 0) {
+		t.Errorf("Expected missing script file to fail")
+	}
+	both := &LuaHealthCheck{Script: "return true", ScriptFile: scriptFile, Timeout: 2 * time.Second}
+	if both.PerformCheck(backend, "test.local.", 0) {
+		t.Errorf("Expected script and script_file to be exclusive")
+	}
+}
+
+func TestLuaHealthCheck_ScriptFileReload(t *testing.T) {
+	scriptFile := filepath.Join(t.TempDir(), "check.lua")
+	load := func(script string) GenericHealthCheck {
+		if err := os.WriteFile(scriptFile, []byte(script), 0600); err != nil {
+			t.Fatalf("Failed to write script: %v", err)
+		}
+		hc := &HealthCheck{Type: "lua", Params: map[string]interface{}{"script_file": scriptFile}}
+		specific, err := hc.ToSpecificHealthCheck()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return specific
+	}
+
+	first := load("return true")
+	if !first.Equals(load("return true")) {
+		t.Errorf("Expected checks of the same script to be equal")
+	}
+	// An edited script file is applied on reload
+	edited := load("return false")
+	if first.Equals(edited) {
+		t.Errorf("Expected an edited script file to change the check")
+	}
+	backend := &Backend{Address: "127.0.0.1", HealthChecks: []GenericHealthCheck{first}}
+	backend.updateBackend(&Backend{Address: "127.0.0.1", HealthChecks: []GenericHealthCheck{edited}})
+	if backend.HealthChecks[0].PerformCheck(backend, "test.local.", 0) {
+		t.Errorf("Expected the edited script to be run")
+	}
+}
+
+func TestLuaHealthCheck_SSHOptions(t *testing.T) {
+	// Host keys are verified by default, an unreadable known_hosts file is an error
+	check := &LuaHealthCheck{
+		Script: `
+			local out, err = ssh_exec({host="127.0.0.1", user="root", password="x", known_hosts="/nonexistent/known_hosts", command="true"})
+			return out == nil and string.find(err, "known_hosts") ~= nil
+		`,
+		Timeout: 2 * time.Second,
+	}
+	if !check.PerformCheck(&Backend{Address: "127.0.0.1"}, "test.local.", 0) {
+		t.Errorf("Expected ssh_exec to require a valid known_hosts file")
+	}
+
+	check = &LuaHealthCheck{
+		Script:  `local out, err = ssh_exec({host="127.0.0.1", user="root", key_file="/nonexistent/id_ed25519", command="true"}) return out == nil and err ~= nil`,
+		Timeout: 2 * time.Second,
+	}
+	if !check.PerformCheck(&Backend{Address: "127.0.0.1"}, "test.local.", 0) {
+		t.Errorf("Expected ssh_exec to fail with a missing key file")
+	}
+}
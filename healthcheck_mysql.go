@@ -1,22 +1,44 @@
 package gslb
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	mysqlRolePrimary = "primary"
+	mysqlRoleReplica = "replica"
 )
 
 // MySQLHealthCheck represents MySQL-specific health check settings.
 type MySQLHealthCheck struct {
-	Host     string `yaml:"host"`
-	Port     int    `yaml:"port" default:"3306"`
-	User     string `yaml:"user"`
-	Password string `yaml:"password"`
-	Database string `yaml:"database"`
-	Timeout  string `yaml:"timeout" default:"3s"`
-	Query    string `yaml:"query" default:"SELECT 1"`
+	Host              string `yaml:"host"`                     // Server address (default: backend address)
+	Port              int    `yaml:"port" default:"3306"`      // Server port
+	User              string `yaml:"user"`                     // Username
+	Password          string `yaml:"password"`                 // Password
+	Database          string `yaml:"database"`                 // Database to connect to
+	Timeout           string `yaml:"timeout" default:"3s"`     // Connection/query timeout
+	Query             string `yaml:"query" default:"SELECT 1"` // Query to execute
+	ExpectedResult    string `yaml:"expected_result"`          // Expected value of the first column of the first row
+	TLS               string `yaml:"tls"`                      // "", true, skip-verify or preferred
+	CAFile            string `yaml:"ca_file"`                  // CA certificate file
+	ClientCert        string `yaml:"client_cert"`              // Client certificate file
+	ClientKey         string `yaml:"client_key"`               // Client key file
+	ServerName        string `yaml:"server_name"`              // Expected server name (default: host)
+	Role              string `yaml:"role"`                     // Expected role from read_only: primary, replica or empty for any
+	MaxReplicationLag string `yaml:"max_replication_lag"`      // Maximum Seconds_Behind_Source on a replica (e.g. 10s)
+	WsrepLocalState   int    `yaml:"wsrep_local_state"`        // Expected Galera wsrep_local_state, e.g. 4 for Synced (0 disables)
+
+	// Source address, interface and mark of the probes
+	SourceBinding `yaml:",inline"`
 }
 
 func (h *MySQLHealthCheck) SetDefault() {
@@ -35,6 +57,45 @@ func (h *MySQLHealthCheck) GetType() string {
 	return fmt.Sprintf("mysql/%d", h.Port)
 }
 
+// buildConfig returns the driver configuration for the given host.
+func (h *MySQLHealthCheck) buildConfig(host string, timeout time.Duration) (*mysql.Config, error) {
+	cfg := mysql.NewConfig()
+	cfg.User = h.User
+	cfg.Passwd = h.Password
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(host, strconv.Itoa(h.Port))
+	cfg.DBName = h.Database
+	cfg.Timeout = timeout
+	cfg.ReadTimeout = timeout
+	cfg.WriteTimeout = timeout
+	if source := h.SourceBinding; source.isSet() {
+		dialer, err := source.dialer(timeout)
+		if err != nil {
+			return nil, err
+		}
+		cfg.DialFunc = dialer.DialContext
+	}
+
+	switch h.TLS {
+	case "", "false":
+	case "preferred":
+		cfg.TLSConfig = h.TLS
+	case "true", "skip-verify":
+		serverName := h.ServerName
+		if serverName == "" {
+			serverName = host
+		}
+		tlsConfig, err := newClientTLSConfig(serverName, h.CAFile, h.ClientCert, h.ClientKey, h.TLS == "skip-verify")
+		if err != nil {
+			return nil, err
+		}
+		cfg.TLS = tlsConfig
+	default:
+		return nil, fmt.Errorf("invalid tls value '%s', expected true, skip-verify or preferred", h.TLS)
+	}
+	return cfg, nil
+}
+
 func (h *MySQLHealthCheck) PerformCheck(backend *Backend, fqdn string, maxRetries int) bool {
 	h.SetDefault()
 	typeStr := h.GetType()
@@ -45,56 +106,188 @@ func (h *MySQLHealthCheck) PerformCheck(backend *Backend, fqdn string, maxRetrie
 		ObserveHealthcheck(fqdn, typeStr, address, start, result)
 	}()
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?timeout=%s", h.User, h.Password, h.Host, h.Port, h.Database, h.Timeout)
 	timeout, err := time.ParseDuration(h.Timeout)
 	if err != nil {
 		log.Errorf("[mysql] invalid timeout format: %v", err)
-		IncHealthcheckFailures(typeStr, address, "timeout")
+		backend.ReportFailure(typeStr, "timeout", err)
 		return false
 	}
 
+	var maxLag time.Duration
+	if h.MaxReplicationLag != "" {
+		maxLag, err = time.ParseDuration(h.MaxReplicationLag)
+		if err != nil {
+			log.Errorf("[%s] invalid max_replication_lag format: %v", fqdn, err)
+			backend.ReportFailure(typeStr, "other", err)
+			return false
+		}
+	}
+
+	host := h.Host
+	if host == "" {
+		host = backend.Address
+	}
+	cfg, err := h.buildConfig(host, timeout)
+	if err != nil {
+		log.Errorf("[%s] mysql healthcheck: %v", fqdn, err)
+		backend.ReportFailure(typeStr, "other", err)
+		return false
+	}
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		log.Errorf("[%s] mysql healthcheck: %v", fqdn, err)
+		backend.ReportFailure(typeStr, "other", err)
+		return false
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxLifetime(timeout)
+	db.SetConnMaxIdleTime(timeout)
+
 	for retry := 0; retry <= maxRetries; retry++ {
-		db, err := sql.Open("mysql", dsn)
+		reason, err := h.check(db, timeout, maxLag)
 		if err != nil {
-			log.Debugf("[mysql] connection failed: %v", err)
+			log.Debugf("[%s] mysql healthcheck failed (retries=%d/%d): [backend=%s] %v", fqdn, retry, maxRetries, cfg.Addr, err)
 			if retry == maxRetries {
-				IncHealthcheckFailures(typeStr, address, "connection")
+				backend.ReportFailure(typeStr, reason, err)
 				return false
 			}
 			continue
 		}
-		db.SetConnMaxLifetime(timeout)
-		db.SetConnMaxIdleTime(timeout)
-		defer db.Close()
+		log.Debugf("[%s] mysql healthcheck success [backend=%s]", fqdn, cfg.Addr)
+		result = true
+		return true
+	}
 
-		// Ping to check connection
-		pingErr := db.Ping()
-		if pingErr != nil {
-			log.Debugf("[mysql] ping failed: %v", pingErr)
-			if retry == maxRetries {
-				IncHealthcheckFailures(typeStr, address, "connection")
-				return false
+	backend.ReportFailure(typeStr, "other", nil)
+	return false
+}
+
+// check runs the query and the configured assertions, returning a failure reason with the error.
+func (h *MySQLHealthCheck) check(db *sql.DB, timeout time.Duration, maxLag time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		return "connection", fmt.Errorf("ping failed: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx, h.Query)
+	if err != nil {
+		return "protocol", fmt.Errorf("query failed: %w", err)
+	}
+	values, err := scanFirstRow(rows)
+	if err != nil {
+		return "protocol", fmt.Errorf("query failed: %w", err)
+	}
+	if h.ExpectedResult != "" && values[0].String != h.ExpectedResult {
+		return "protocol", fmt.Errorf("query result mismatch: got '%s', want '%s'", values[0].String, h.ExpectedResult)
+	}
+
+	if h.Role != "" {
+		var readOnly bool
+		if err := db.QueryRowContext(ctx, "SELECT @@global.read_only").Scan(&readOnly); err != nil {
+			return "protocol", fmt.Errorf("read_only query failed: %w", err)
+		}
+		switch h.Role {
+		case mysqlRolePrimary:
+			if readOnly {
+				return "protocol", fmt.Errorf("server is read_only, expected primary")
 			}
-			continue
+		case mysqlRoleReplica:
+			if !readOnly {
+				return "protocol", fmt.Errorf("server is writable, expected replica")
+			}
+		default:
+			return "other", fmt.Errorf("unknown role '%s', expected %s or %s", h.Role, mysqlRolePrimary, mysqlRoleReplica)
 		}
+	}
 
-		// Execute the query
-		row := db.QueryRow(h.Query)
-		var dummy int
-		if err := row.Scan(&dummy); err != nil {
-			log.Debugf("[mysql] query failed: %v", err)
-			if retry == maxRetries {
-				IncHealthcheckFailures(typeStr, address, "protocol")
-				return false
-			}
+	if maxLag > 0 {
+		if err := checkMySQLReplicationLag(ctx, db, maxLag); err != nil {
+			return "protocol", err
+		}
+	}
+
+	if h.WsrepLocalState > 0 {
+		var name, state string
+		if err := db.QueryRowContext(ctx, "SHOW GLOBAL STATUS LIKE 'wsrep_local_state'").Scan(&name, &state); err != nil {
+			return "protocol", fmt.Errorf("wsrep_local_state query failed: %w", err)
+		}
+		if state != strconv.Itoa(h.WsrepLocalState) {
+			return "protocol", fmt.Errorf("wsrep_local_state is %s, expected %d", state, h.WsrepLocalState)
+		}
+	}
+	return "", nil
+}
+
+// checkMySQLReplicationLag reads SHOW REPLICA STATUS and compares Seconds_Behind_Source with maxLag.
+// A server which is not a replica has no lag and passes.
+func checkMySQLReplicationLag(ctx context.Context, db *sql.DB, maxLag time.Duration) error {
+	rows, err := db.QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		// Servers older than MySQL 8.0.22 only know the legacy syntax
+		rows, err = db.QueryContext(ctx, "SHOW SLAVE STATUS")
+		if err != nil {
+			return fmt.Errorf("replica status query failed: %w", err)
+		}
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("replica status query failed: %w", err)
+	}
+	values, err := scanFirstRow(rows)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("replica status query failed: %w", err)
+	}
+
+	for i, column := range columns {
+		if !strings.EqualFold(column, "Seconds_Behind_Source") && !strings.EqualFold(column, "Seconds_Behind_Master") {
 			continue
 		}
-		result = true
-		return true
+		if !values[i].Valid {
+			return fmt.Errorf("replication is not running (%s is NULL)", column)
+		}
+		lag, err := strconv.Atoi(values[i].String)
+		if err != nil {
+			return fmt.Errorf("invalid %s value '%s'", column, values[i].String)
+		}
+		if time.Duration(lag)*time.Second > maxLag {
+			return fmt.Errorf("replication lag %ds exceeds %s", lag, maxLag)
+		}
+		return nil
 	}
+	return fmt.Errorf("replica status has no Seconds_Behind_Source column")
+}
 
-	IncHealthcheckFailures(typeStr, address, "other")
-	return false
+// scanFirstRow reads every column of the first row and closes rows.
+func scanFirstRow(rows *sql.Rows) ([]sql.NullString, error) {
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, sql.ErrNoRows
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return values, nil
 }
 
 func (h *MySQLHealthCheck) Equals(other GenericHealthCheck) bool {
@@ -102,5 +295,21 @@ func (h *MySQLHealthCheck) Equals(other GenericHealthCheck) bool {
 	if !ok {
 		return false
 	}
-	return h.Host == otherMySQL.Host && h.Port == otherMySQL.Port && h.User == otherMySQL.User && h.Database == otherMySQL.Database && h.Query == otherMySQL.Query
+	return h.Host == otherMySQL.Host &&
+		h.Port == otherMySQL.Port &&
+		h.User == otherMySQL.User &&
+		h.Password == otherMySQL.Password &&
+		h.Database == otherMySQL.Database &&
+		h.Timeout == otherMySQL.Timeout &&
+		h.Query == otherMySQL.Query &&
+		h.ExpectedResult == otherMySQL.ExpectedResult &&
+		h.TLS == otherMySQL.TLS &&
+		h.CAFile == otherMySQL.CAFile &&
+		h.ClientCert == otherMySQL.ClientCert &&
+		h.ClientKey == otherMySQL.ClientKey &&
+		h.ServerName == otherMySQL.ServerName &&
+		h.Role == otherMySQL.Role &&
+		h.MaxReplicationLag == otherMySQL.MaxReplicationLag &&
+		h.WsrepLocalState == otherMySQL.WsrepLocalState &&
+		h.SourceBinding == otherMySQL.SourceBinding
 }
@@ -1,7 +1,9 @@
 package gslb
 
 import (
+	"net"
 	"testing"
+	"time"
 )
 
 func TestMySQLHealthCheck_Defaults(t *testing.T) {
@@ -22,10 +24,77 @@ func TestMySQLHealthCheck_Equals(t *testing.T) {
 	h1 := &MySQLHealthCheck{Host: "127.0.0.1", Port: 3306, User: "a", Database: "b", Query: "SELECT 1"}
 	h2 := &MySQLHealthCheck{Host: "127.0.0.1", Port: 3306, User: "a", Database: "b", Query: "SELECT 1"}
 	h3 := &MySQLHealthCheck{Host: "127.0.0.2", Port: 3306, User: "a", Database: "b", Query: "SELECT 1"}
+	h4 := &MySQLHealthCheck{Host: "127.0.0.1", Port: 3306, User: "a", Database: "b", Query: "SELECT 1", Role: "primary"}
 	if !h1.Equals(h2) {
 		t.Error("expected h1 == h2")
 	}
 	if h1.Equals(h3) {
 		t.Error("expected h1 != h3")
 	}
+	if h1.Equals(h4) {
+		t.Error("expected h1 != h4")
+	}
+	h5 := &MySQLHealthCheck{Host: "127.0.0.1", Port: 3306, User: "a", Password: "rotated", Database: "b", Query: "SELECT 1"}
+	if h1.Equals(h5) {
+		t.Error("expected h1 != h5")
+	}
+	h6 := &MySQLHealthCheck{Host: "127.0.0.1", Port: 3306, User: "a", Database: "b", Query: "SELECT 1", Timeout: "10s"}
+	if h1.Equals(h6) {
+		t.Error("expected h1 != h6")
+	}
+}
+
+func TestMySQLHealthCheck_BuildConfig(t *testing.T) {
+	h := &MySQLHealthCheck{Port: 3306, User: "gslb", Password: "secret", Database: "app"}
+	cfg, err := h.buildConfig("10.0.0.5", 3*time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Addr != "10.0.0.5:3306" || cfg.User != "gslb" || cfg.DBName != "app" || cfg.Timeout != 3*time.Second {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if cfg.TLS != nil {
+		t.Error("expected TLS to be disabled by default")
+	}
+
+	h.TLS = "skip-verify"
+	cfg, err = h.buildConfig("10.0.0.5", time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.TLS == nil || !cfg.TLS.InsecureSkipVerify || cfg.TLS.ServerName != "10.0.0.5" {
+		t.Errorf("unexpected TLS config: %+v", cfg.TLS)
+	}
+
+	h.TLS = "invalid"
+	if _, err := h.buildConfig("10.0.0.5", time.Second); err == nil {
+		t.Error("expected error for invalid tls value")
+	}
+}
+
+func TestMySQLHealthCheck_DefaultsToBackendAddress(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find free port: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	accepted := make(chan struct{}, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			accepted <- struct{}{}
+			conn.Close()
+		}
+	}()
+	defer ln.Close()
+
+	h := &MySQLHealthCheck{Port: port, Timeout: "1s"}
+	if h.PerformCheck(&Backend{Address: "127.0.0.1"}, "example.com.", 0) {
+		t.Error("expected mysql healthcheck to fail against a non-MySQL server")
+	}
+	select {
+	case <-accepted:
+	default:
+		t.Error("expected the check to connect to the backend address")
+	}
 }
@@ -0,0 +1,217 @@
+package gslb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+const (
+	postgresRolePrimary = "primary"
+	postgresRoleReplica = "replica"
+)
+
+// postgresLagQuery returns the replication lag of a replica in seconds, 0 when fully replayed.
+const postgresLagQuery = `SELECT CASE
+	WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END`
+
+// PostgresHealthCheck represents PostgreSQL-specific health check settings.
+type PostgresHealthCheck struct {
+	Host              string `yaml:"host"`                // Server address (default: backend address)
+	Port              int    `yaml:"port"`                // Server port
+	User              string `yaml:"user"`                // Username
+	Password          string `yaml:"password"`            // Password
+	Database          string `yaml:"database"`            // Database to connect to
+	SSLMode           string `yaml:"sslmode"`             // disable, require, verify-ca or verify-full
+	SSLRootCert       string `yaml:"sslrootcert"`         // CA certificate file
+	SSLCert           string `yaml:"sslcert"`             // Client certificate file
+	SSLKey            string `yaml:"sslkey"`              // Client key file
+	Timeout           string `yaml:"timeout"`             // Connection/query timeout
+	Query             string `yaml:"query"`               // Query to execute
+	Role              string `yaml:"role"`                // Expected role: primary, replica or empty for any
+	MaxReplicationLag string `yaml:"max_replication_lag"` // Maximum replay lag on a replica (e.g. 10s)
+}
+
+func (h *PostgresHealthCheck) SetDefault() {
+	if h.Port == 0 {
+		h.Port = 5432
+	}
+	if h.SSLMode == "" {
+		h.SSLMode = "disable"
+	}
+	if h.Timeout == "" {
+		h.Timeout = "3s"
+	}
+	if h.Query == "" {
+		h.Query = "SELECT 1"
+	}
+}
+
+func (h *PostgresHealthCheck) GetType() string {
+	return fmt.Sprintf("postgres/%d", h.Port)
+}
+
+// buildDSN returns the connection URL for the given host.
+func (h *PostgresHealthCheck) buildDSN(host string, timeout time.Duration) string {
+	query := url.Values{}
+	query.Set("sslmode", h.SSLMode)
+	// connect_timeout is in whole seconds and 0 means wait indefinitely
+	query.Set("connect_timeout", strconv.Itoa(int(math.Max(1, math.Ceil(timeout.Seconds())))))
+	if h.SSLRootCert != "" {
+		query.Set("sslrootcert", h.SSLRootCert)
+	}
+	if h.SSLCert != "" {
+		query.Set("sslcert", h.SSLCert)
+	}
+	if h.SSLKey != "" {
+		query.Set("sslkey", h.SSLKey)
+	}
+	dsn := url.URL{
+		Scheme:   "postgres",
+		Host:     net.JoinHostPort(host, strconv.Itoa(h.Port)),
+		Path:     "/" + h.Database,
+		RawQuery: query.Encode(),
+	}
+	if h.User != "" {
+		dsn.User = url.UserPassword(h.User, h.Password)
+	}
+	return dsn.String()
+}
+
+func (h *PostgresHealthCheck) PerformCheck(backend *Backend, fqdn string, maxRetries int) bool {
+	h.SetDefault()
+	typeStr := h.GetType()
+	address := backend.Address
+	start := time.Now()
+	result := false
+	defer func() {
+		ObserveHealthcheck(fqdn, typeStr, address, start, result)
+	}()
+
+	timeout, err := time.ParseDuration(h.Timeout)
+	if err != nil {
+		log.Errorf("[%s] invalid timeout format: %v", fqdn, err)
+		backend.ReportFailure(typeStr, "timeout", err)
+		return false
+	}
+	var maxLag time.Duration
+	if h.MaxReplicationLag != "" {
+		maxLag, err = time.ParseDuration(h.MaxReplicationLag)
+		if err != nil {
+			log.Errorf("[%s] invalid max_replication_lag format: %v", fqdn, err)
+			backend.ReportFailure(typeStr, "other", err)
+			return false
+		}
+	}
+
+	host := h.Host
+	if host == "" {
+		host = backend.Address
+	}
+	db, err := sql.Open("postgres", h.buildDSN(host, timeout))
+	if err != nil {
+		log.Errorf("[%s] postgres healthcheck: %v", fqdn, err)
+		backend.ReportFailure(typeStr, "other", err)
+		return false
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxLifetime(timeout)
+
+	for retry := 0; retry <= maxRetries; retry++ {
+		reason, err := h.check(db, timeout, maxLag)
+		if err != nil {
+			log.Debugf("[%s] postgres healthcheck failed (retries=%d/%d): [backend=%s:%d] %v", fqdn, retry, maxRetries, host, h.Port, err)
+			if retry == maxRetries {
+				backend.ReportFailure(typeStr, reason, err)
+				return false
+			}
+			continue
+		}
+		log.Debugf("[%s] postgres healthcheck success [backend=%s:%d]", fqdn, host, h.Port)
+		result = true
+		return true
+	}
+
+	backend.ReportFailure(typeStr, "other", nil)
+	return false
+}
+
+// check runs the query and the role/lag assertions, returning a failure reason with the error.
+func (h *PostgresHealthCheck) check(db *sql.DB, timeout time.Duration, maxLag time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		return "connection", fmt.Errorf("ping failed: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx, h.Query)
+	if err != nil {
+		return "protocol", fmt.Errorf("query failed: %w", err)
+	}
+	rows.Close()
+
+	if h.Role == "" && maxLag == 0 {
+		return "", nil
+	}
+
+	var inRecovery bool
+	if err := db.QueryRowContext(ctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery); err != nil {
+		return "protocol", fmt.Errorf("pg_is_in_recovery() failed: %w", err)
+	}
+	switch h.Role {
+	case postgresRolePrimary:
+		if inRecovery {
+			return "protocol", fmt.Errorf("server is a replica, expected primary")
+		}
+	case postgresRoleReplica:
+		if !inRecovery {
+			return "protocol", fmt.Errorf("server is a primary, expected replica")
+		}
+	case "":
+	default:
+		return "other", fmt.Errorf("unknown role '%s', expected %s or %s", h.Role, postgresRolePrimary, postgresRoleReplica)
+	}
+
+	if inRecovery && maxLag > 0 {
+		var lagSeconds float64
+		if err := db.QueryRowContext(ctx, postgresLagQuery).Scan(&lagSeconds); err != nil {
+			return "protocol", fmt.Errorf("replication lag query failed: %w", err)
+		}
+		lag := time.Duration(lagSeconds * float64(time.Second))
+		if lag > maxLag {
+			return "protocol", fmt.Errorf("replication lag %s exceeds %s", lag, maxLag)
+		}
+	}
+	return "", nil
+}
+
+func (h *PostgresHealthCheck) Equals(other GenericHealthCheck) bool {
+	otherPG, ok := other.(*PostgresHealthCheck)
+	if !ok {
+		return false
+	}
+	return h.Host == otherPG.Host &&
+		h.Port == otherPG.Port &&
+		h.User == otherPG.User &&
+		h.Password == otherPG.Password &&
+		h.Database == otherPG.Database &&
+		h.SSLMode == otherPG.SSLMode &&
+		h.SSLRootCert == otherPG.SSLRootCert &&
+		h.SSLCert == otherPG.SSLCert &&
+		h.SSLKey == otherPG.SSLKey &&
+		h.Timeout == otherPG.Timeout &&
+		h.Query == otherPG.Query &&
+		h.Role == otherPG.Role &&
+		h.MaxReplicationLag == otherPG.MaxReplicationLag
+}
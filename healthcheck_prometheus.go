@@ -0,0 +1,464 @@
+package gslb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/creasty/defaults"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// PrometheusHealthCheck compares a metric value with a threshold. The value is either scraped
+// from an exposition endpoint of the backend or returned by a PromQL instant query.
+type PrometheusHealthCheck struct {
+	URL           string            `yaml:"url" default:""`                  // Prometheus API base URL with query, scrape URL otherwise (default: backend endpoint)
+	Port          int               `yaml:"port" default:"9100"`             // Port of the backend exposition endpoint
+	Path          string            `yaml:"path" default:"/metrics"`         // Path of the backend exposition endpoint
+	EnableTLS     bool              `yaml:"enable_tls" default:"false"`      // Use HTTPS to reach the backend exposition endpoint
+	SkipTLSVerify bool              `yaml:"skip_tls_verify" default:"false"` // Skip TLS certificate validation
+	Headers       map[string]string `yaml:"headers"`                         // Additional HTTP headers, e.g. Authorization
+	Timeout       string            `yaml:"timeout" default:"5s"`            // Timeout of the HTTP request
+	Metric        string            `yaml:"metric" default:""`               // Series selector for scraping, e.g. http_requests_total{code=~"5.."}
+	Query         string            `yaml:"query" default:""`                // PromQL instant query
+	Aggregate     string            `yaml:"aggregate" default:"sum"`         // How to combine several series: sum, min, max, avg or count
+	Operator      string            `yaml:"operator" default:"<"`            // Comparison which must hold to be healthy: <, <=, >, >=, == or !=
+	Threshold     float64           `yaml:"threshold" default:"0"`           // Value compared with the metric
+	AllowEmpty    bool              `yaml:"allow_empty" default:"false"`     // If true, no matching series means healthy
+}
+
+// labelMatcher is a single label matcher of a series selector.
+type labelMatcher struct {
+	name  string
+	op    string
+	value string
+	re    *regexp.Regexp
+}
+
+// seriesSelector selects series by metric name and label matchers, like PromQL.
+type seriesSelector struct {
+	name     string
+	matchers []labelMatcher
+}
+
+// selectorRe splits a selector into the metric name and the label matchers block.
+var selectorRe = regexp.MustCompile(`^\s*([a-zA-Z_:][a-zA-Z0-9_:]*)\s*(?:\{(.*)\})?\s*$`)
+
+// matcherRe matches one label matcher with a double-quoted value.
+var matcherRe = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*(=~|!~|!=|=)\s*"((?:[^"\\]|\\.)*)"\s*(?:,|$)`)
+
+// parseSeriesSelector parses a selector such as http_requests_total{code=~"5..",method="GET"}.
+func parseSeriesSelector(selector string) (*seriesSelector, error) {
+	parts := selectorRe.FindStringSubmatch(selector)
+	if parts == nil {
+		return nil, fmt.Errorf("invalid series selector '%s'", selector)
+	}
+	s := &seriesSelector{name: parts[1]}
+	rest := parts[2]
+	for strings.TrimSpace(rest) != "" {
+		m := matcherRe.FindStringSubmatch(rest)
+		if m == nil {
+			return nil, fmt.Errorf("invalid label matcher '%s' in series selector '%s'", strings.TrimSpace(rest), selector)
+		}
+		value, err := strconv.Unquote(`"` + m[3] + `"`)
+		if err != nil {
+			return nil, fmt.Errorf("invalid label value in series selector '%s': %w", selector, err)
+		}
+		matcher := labelMatcher{name: m[1], op: m[2], value: value}
+		if matcher.op == "=~" || matcher.op == "!~" {
+			// Regex matchers are fully anchored, as in PromQL
+			if matcher.re, err = regexp.Compile("^(?:" + value + ")$"); err != nil {
+				return nil, fmt.Errorf("invalid regex in series selector '%s': %w", selector, err)
+			}
+		}
+		s.matchers = append(s.matchers, matcher)
+		rest = rest[len(m[0]):]
+	}
+	return s, nil
+}
+
+// matches reports whether the labels satisfy every matcher, a missing label being empty.
+func (s *seriesSelector) matches(labels []*dto.LabelPair) bool {
+	for _, matcher := range s.matchers {
+		value := ""
+		for _, label := range labels {
+			if label.GetName() == matcher.name {
+				value = label.GetValue()
+				break
+			}
+		}
+		var ok bool
+		switch matcher.op {
+		case "=":
+			ok = value == matcher.value
+		case "!=":
+			ok = value != matcher.value
+		case "=~":
+			ok = matcher.re.MatchString(value)
+		case "!~":
+			ok = !matcher.re.MatchString(value)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// selectSamples returns the values of the series matching the selector in the parsed families.
+// The _sum and _count series of summaries and histograms can be selected too.
+func (s *seriesSelector) selectSamples(families map[string]*dto.MetricFamily) []float64 {
+	name, suffix := s.name, ""
+	if _, found := families[name]; !found {
+		for _, candidate := range []string{"_sum", "_count"} {
+			if strings.HasSuffix(s.name, candidate) {
+				name, suffix = strings.TrimSuffix(s.name, candidate), candidate
+			}
+		}
+	}
+	family, found := families[name]
+	if !found {
+		return nil
+	}
+
+	var values []float64
+	for _, metric := range family.GetMetric() {
+		if !s.matches(metric.GetLabel()) {
+			continue
+		}
+		switch {
+		case metric.Gauge != nil && suffix == "":
+			values = append(values, metric.GetGauge().GetValue())
+		case metric.Counter != nil && suffix == "":
+			values = append(values, metric.GetCounter().GetValue())
+		case metric.Untyped != nil && suffix == "":
+			values = append(values, metric.GetUntyped().GetValue())
+		case metric.Summary != nil && suffix == "_sum":
+			values = append(values, metric.GetSummary().GetSampleSum())
+		case metric.Summary != nil && suffix == "_count":
+			values = append(values, float64(metric.GetSummary().GetSampleCount()))
+		case metric.Histogram != nil && suffix == "_sum":
+			values = append(values, metric.GetHistogram().GetSampleSum())
+		case metric.Histogram != nil && suffix == "_count":
+			values = append(values, float64(metric.GetHistogram().GetSampleCount()))
+		}
+	}
+	return values
+}
+
+// SetDefault applies default values to PrometheusHealthCheck fields.
+func (h *PrometheusHealthCheck) SetDefault() {
+	defaults.Set(h)
+}
+
+// GetType returns the type of the health check as a string.
+func (h *PrometheusHealthCheck) GetType() string {
+	return "prometheus"
+}
+
+// validate checks the mode and the comparison settings.
+func (h *PrometheusHealthCheck) validate() error {
+	if (h.Metric == "") == (h.Query == "") {
+		return fmt.Errorf("either metric or query must be set")
+	}
+	if h.Query != "" && h.URL == "" {
+		return fmt.Errorf("url of the Prometheus API is required with query")
+	}
+	switch h.Operator {
+	case "<", "<=", ">", ">=", "==", "!=":
+	default:
+		return fmt.Errorf("unsupported operator '%s'", h.Operator)
+	}
+	switch h.Aggregate {
+	case "sum", "min", "max", "avg", "count":
+	default:
+		return fmt.Errorf("unsupported aggregate '%s'", h.Aggregate)
+	}
+	return nil
+}
+
+// expandPrometheusPlaceholders replaces the {address} and {fqdn} placeholders of the metric selector or query.
+func expandPrometheusPlaceholders(text string, backend *Backend, fqdn string) string {
+	return strings.NewReplacer("{address}", backend.Address, "{fqdn}", fqdn).Replace(text)
+}
+
+// PerformCheck fetches the metric value and compares it with the threshold.
+func (h *PrometheusHealthCheck) PerformCheck(backend *Backend, fqdn string, maxRetries int) bool {
+	typeStr := h.GetType()
+	address := backend.Address
+	start := time.Now()
+	result := false
+	defer func() {
+		ObserveHealthcheck(fqdn, typeStr, address, start, result)
+	}()
+
+	timeout, err := time.ParseDuration(h.Timeout)
+	if err != nil {
+		log.Errorf("[%s] invalid timeout format: %v", fqdn, err)
+		backend.ReportFailure(typeStr, "timeout", err)
+		return false
+	}
+	if err := h.validate(); err != nil {
+		log.Errorf("[%s] invalid prometheus healthcheck: %v", fqdn, err)
+		backend.ReportFailure(typeStr, "other", err)
+		return false
+	}
+	var selector *seriesSelector
+	if h.Metric != "" {
+		if selector, err = parseSeriesSelector(expandPrometheusPlaceholders(h.Metric, backend, fqdn)); err != nil {
+			log.Errorf("[%s] invalid prometheus healthcheck: %v", fqdn, err)
+			backend.ReportFailure(typeStr, "other", err)
+			return false
+		}
+	}
+
+	tlsConfig, err := newClientTLSConfig("", "", "", "", h.SkipTLSVerify)
+	if err != nil {
+		log.Errorf("[%s] invalid TLS settings: %v", fqdn, err)
+		backend.ReportFailure(typeStr, "other", err)
+		return false
+	}
+	client, err := createHTTPClient(httpProtocolHTTP1, tlsConfig, timeout, SourceBinding{}, nil)
+	if err != nil {
+		backend.ReportFailure(typeStr, "other", err)
+		return false
+	}
+	defer closeHTTPClient(client)
+
+	for retry := 0; retry <= maxRetries; retry++ {
+		var values []float64
+		var reason string
+		if selector != nil {
+			values, reason, err = h.scrape(client, selector, backend, timeout)
+		} else {
+			values, reason, err = h.query(client, expandPrometheusPlaceholders(h.Query, backend, fqdn), timeout)
+		}
+		if err == nil {
+			err = h.evaluate(values)
+			reason = "protocol"
+		}
+		if err != nil {
+			log.Debugf("[%s] prometheus healthcheck failed (retries=%d/%d): [backend=%s] %v", fqdn, retry, maxRetries, address, err)
+			if retry == maxRetries {
+				backend.ReportFailure(typeStr, reason, err)
+				return false
+			}
+			continue
+		}
+
+		log.Debugf("[%s] prometheus healthcheck success [backend=%s]", fqdn, address)
+		result = true
+		return true
+	}
+
+	backend.ReportFailure(typeStr, "other", nil)
+	return false
+}
+
+// get performs a GET request with the configured headers and returns the body.
+func (h *PrometheusHealthCheck) get(client *http.Client, target string, timeout time.Duration) ([]byte, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return nil, "other", err
+	}
+	for key, value := range h.Headers {
+		req.Header.Add(key, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "connection", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "connection", fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "protocol", fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, target)
+	}
+	return body, "", nil
+}
+
+// scrape reads the exposition endpoint and returns the values of the matching series.
+func (h *PrometheusHealthCheck) scrape(client *http.Client, selector *seriesSelector, backend *Backend, timeout time.Duration) ([]float64, string, error) {
+	target := h.URL
+	if target == "" {
+		scheme := "http"
+		if h.EnableTLS {
+			scheme = "https"
+		}
+		target = buildHealthCheckURL(scheme, backend.Address, h.Port, h.Path)
+	}
+	body, reason, err := h.get(client, target, timeout)
+	if err != nil {
+		return nil, reason, err
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(body))
+	if err != nil {
+		return nil, "protocol", fmt.Errorf("invalid exposition format: %w", err)
+	}
+	return selector.selectSamples(families), "", nil
+}
+
+// prometheusQueryResponse is the response of the /api/v1/query endpoint.
+type prometheusQueryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// query runs a PromQL instant query and returns the values of the result.
+func (h *PrometheusHealthCheck) query(client *http.Client, query string, timeout time.Duration) ([]float64, string, error) {
+	target := strings.TrimSuffix(h.URL, "/") + "/api/v1/query?" + url.Values{"query": []string{query}}.Encode()
+	body, reason, err := h.get(client, target, timeout)
+	if err != nil {
+		return nil, reason, err
+	}
+
+	var resp prometheusQueryResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, "protocol", fmt.Errorf("invalid query response: %w", err)
+	}
+	if resp.Status != "success" {
+		return nil, "protocol", fmt.Errorf("query failed: %s", resp.Error)
+	}
+
+	// Sample values are [ <unix time>, "<value>" ]
+	var samples [][2]interface{}
+	switch resp.Data.ResultType {
+	case "vector":
+		var vector []struct {
+			Value [2]interface{} `json:"value"`
+		}
+		if err := json.Unmarshal(resp.Data.Result, &vector); err != nil {
+			return nil, "protocol", fmt.Errorf("invalid query result: %w", err)
+		}
+		for _, sample := range vector {
+			samples = append(samples, sample.Value)
+		}
+	case "scalar":
+		var scalar [2]interface{}
+		if err := json.Unmarshal(resp.Data.Result, &scalar); err != nil {
+			return nil, "protocol", fmt.Errorf("invalid query result: %w", err)
+		}
+		samples = append(samples, scalar)
+	default:
+		return nil, "protocol", fmt.Errorf("unsupported result type '%s', expected vector or scalar", resp.Data.ResultType)
+	}
+
+	values := make([]float64, 0, len(samples))
+	for _, sample := range samples {
+		text, _ := sample[1].(string)
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, "protocol", fmt.Errorf("invalid sample value '%v'", sample[1])
+		}
+		values = append(values, value)
+	}
+	return values, "", nil
+}
+
+// evaluate aggregates the values and compares the result with the threshold.
+func (h *PrometheusHealthCheck) evaluate(values []float64) error {
+	if len(values) == 0 {
+		if h.AllowEmpty {
+			return nil
+		}
+		return fmt.Errorf("no matching series")
+	}
+
+	value := aggregateValues(h.Aggregate, values)
+	var ok bool
+	switch h.Operator {
+	case "<":
+		ok = value < h.Threshold
+	case "<=":
+		ok = value <= h.Threshold
+	case ">":
+		ok = value > h.Threshold
+	case ">=":
+		ok = value >= h.Threshold
+	case "==":
+		ok = value == h.Threshold
+	case "!=":
+		ok = value != h.Threshold
+	}
+	if !ok {
+		return fmt.Errorf("value %g does not satisfy %s %g", value, h.Operator, h.Threshold)
+	}
+	return nil
+}
+
+// aggregateValues combines the values of several series into one.
+func aggregateValues(aggregate string, values []float64) float64 {
+	switch aggregate {
+	case "count":
+		return float64(len(values))
+	case "min":
+		result := math.Inf(1)
+		for _, v := range values {
+			result = math.Min(result, v)
+		}
+		return result
+	case "max":
+		result := math.Inf(-1)
+		for _, v := range values {
+			result = math.Max(result, v)
+		}
+		return result
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	if aggregate == "avg" {
+		return sum / float64(len(values))
+	}
+	return sum
+}
+
+// Equals compares two PrometheusHealthCheck objects for equality.
+func (h *PrometheusHealthCheck) Equals(other GenericHealthCheck) bool {
+	otherProm, ok := other.(*PrometheusHealthCheck)
+	if !ok {
+		return false
+	}
+	if h.URL != otherProm.URL ||
+		h.Port != otherProm.Port ||
+		h.Path != otherProm.Path ||
+		h.EnableTLS != otherProm.EnableTLS ||
+		h.SkipTLSVerify != otherProm.SkipTLSVerify ||
+		h.Timeout != otherProm.Timeout ||
+		h.Metric != otherProm.Metric ||
+		h.Query != otherProm.Query ||
+		h.Aggregate != otherProm.Aggregate ||
+		h.Operator != otherProm.Operator ||
+		h.Threshold != otherProm.Threshold ||
+		h.AllowEmpty != otherProm.AllowEmpty ||
+		len(h.Headers) != len(otherProm.Headers) {
+		return false
+	}
+	for key, value := range h.Headers {
+		if otherValue, exists := otherProm.Headers[key]; !exists || value != otherValue {
+			return false
+		}
+	}
+	return true
+}
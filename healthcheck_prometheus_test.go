@@ -0,0 +1,142 @@
+package gslb
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const prometheusTestExposition = `# HELP http_requests_total Total HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{code="200",method="GET"} 950
+http_requests_total{code="500",method="GET"} 30
+http_requests_total{code="503",method="POST"} 20
+# HELP queue_depth Current queue depth.
+# TYPE queue_depth gauge
+queue_depth{queue="orders"} 120
+queue_depth{queue="mails"} 3
+# HELP request_duration_seconds Request latency.
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{le="0.1"} 8
+request_duration_seconds_bucket{le="+Inf"} 10
+request_duration_seconds_sum 1.5
+request_duration_seconds_count 10
+`
+
+func TestParseSeriesSelector(t *testing.T) {
+	s, err := parseSeriesSelector(`http_requests_total{code=~"5..", method!="POST"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "http_requests_total", s.name)
+	assert.Len(t, s.matchers, 2)
+	assert.Equal(t, "!=", s.matchers[1].op)
+
+	s, err = parseSeriesSelector("up")
+	assert.NoError(t, err)
+	assert.Empty(t, s.matchers)
+
+	for _, selector := range []string{"", "1up", `up{job=prom}`, `up{job="a" job="b"}`, `up{job=~"("}`} {
+		_, err := parseSeriesSelector(selector)
+		assert.Error(t, err, selector)
+	}
+}
+
+func TestPrometheusHealthCheck_Scrape(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/metrics", r.URL.Path)
+		w.Write([]byte(prometheusTestExposition))
+	}))
+	defer server.Close()
+
+	backend := &Backend{Address: "127.0.0.1"}
+	newCheck := func(metric, aggregate, operator string, threshold float64) *PrometheusHealthCheck {
+		hc := &PrometheusHealthCheck{}
+		hc.SetDefault()
+		hc.Port = server.Listener.Addr().(*net.TCPAddr).Port
+		hc.Metric = metric
+		hc.Aggregate = aggregate
+		hc.Operator = operator
+		hc.Threshold = threshold
+		return hc
+	}
+
+	tests := []struct {
+		name    string
+		check   *PrometheusHealthCheck
+		healthy bool
+	}{
+		{"ErrorsBelowThreshold", newCheck(`http_requests_total{code=~"5.."}`, "sum", "<", 100), true},
+		{"ErrorsAboveThreshold", newCheck(`http_requests_total{code=~"5.."}`, "sum", "<", 40), false},
+		{"LabelMatchers", newCheck(`http_requests_total{code=~"5..",method="GET"}`, "sum", "<", 40), true},
+		{"MaxGauge", newCheck(`queue_depth`, "max", "<=", 100), false},
+		{"MinGauge", newCheck(`queue_depth`, "min", "<=", 100), true},
+		{"CountSeries", newCheck(`queue_depth`, "count", "==", 2), true},
+		{"HistogramCount", newCheck(`request_duration_seconds_count`, "sum", ">=", 10), true},
+		{"NoSeries", newCheck(`queue_depth{queue="none"}`, "sum", "<", 1), false},
+		{"UnknownMetric", newCheck(`missing_metric`, "sum", "<", 1), false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.healthy, test.check.PerformCheck(backend, "example.com.", 0))
+		})
+	}
+
+	allowEmpty := newCheck(`queue_depth{queue="none"}`, "sum", "<", 1)
+	allowEmpty.AllowEmpty = true
+	assert.True(t, allowEmpty.PerformCheck(backend, "example.com.", 0))
+}
+
+func TestPrometheusHealthCheck_Query(t *testing.T) {
+	var lastQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/query", r.URL.Path)
+		lastQuery = r.URL.Query().Get("query")
+		switch lastQuery {
+		case "scalar(1)":
+			w.Write([]byte(`{"status":"success","data":{"resultType":"scalar","result":[1700000000,"1"]}}`))
+		case "bad(":
+			w.WriteHeader(400)
+			w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`))
+		default:
+			w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{"instance":"10.0.0.1"},"value":[1700000000,"0.02"]}]}}`))
+		}
+	}))
+	defer server.Close()
+
+	backend := &Backend{Address: "10.0.0.1"}
+	hc := &PrometheusHealthCheck{}
+	hc.SetDefault()
+	hc.URL = server.URL
+	hc.Query = `sum(rate(http_requests_total{instance="{address}",code=~"5.."}[5m]))`
+	hc.Threshold = 0.05
+	assert.True(t, hc.PerformCheck(backend, "example.com.", 0))
+	assert.Equal(t, `sum(rate(http_requests_total{instance="10.0.0.1",code=~"5.."}[5m]))`, lastQuery)
+
+	hc.Threshold = 0.01
+	assert.False(t, hc.PerformCheck(backend, "example.com.", 0))
+
+	hc.Query = "scalar(1)"
+	hc.Operator = "=="
+	hc.Threshold = 1
+	assert.True(t, hc.PerformCheck(backend, "example.com.", 0))
+
+	hc.Query = "bad("
+	assert.False(t, hc.PerformCheck(backend, "example.com.", 0))
+
+	noURL := &PrometheusHealthCheck{}
+	noURL.SetDefault()
+	noURL.Query = "up"
+	assert.False(t, noURL.PerformCheck(backend, "example.com.", 0))
+}
+
+func TestPrometheusHealthCheck_Equals(t *testing.T) {
+	hc1 := &PrometheusHealthCheck{Metric: "up", Operator: ">=", Threshold: 1}
+	hc2 := &PrometheusHealthCheck{Metric: "up", Operator: ">=", Threshold: 1}
+	hc3 := &PrometheusHealthCheck{Metric: "up", Operator: ">=", Threshold: 2}
+
+	assert.True(t, hc1.Equals(hc2))
+	assert.False(t, hc1.Equals(hc3))
+	assert.False(t, hc1.Equals(&TCPHealthCheck{}))
+}
@@ -0,0 +1,122 @@
+package gslb
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/creasty/defaults"
+)
+
+// PushHealthCheck is a passive health check, the backend health is reported by an
+// external agent through the API or a DNS UPDATE and expires after a TTL.
+type PushHealthCheck struct {
+	TTL   string `yaml:"ttl" default:"60s"` // Validity of a report when the agent does not set one
+	Token string `yaml:"token" default:""`  // Bearer token accepted by the API instead of the basic auth credentials
+
+	mutex       sync.Mutex
+	healthy     bool
+	message     string
+	expires     time.Time
+	reported    bool
+	expiryTimer *time.Timer // Evaluates the check again when the last report expires
+}
+
+// SetDefault applies default values to PushHealthCheck fields.
+func (h *PushHealthCheck) SetDefault() {
+	defaults.Set(h)
+}
+
+// GetType returns the type of the health check as a string.
+func (h *PushHealthCheck) GetType() string {
+	return "push"
+}
+
+// Report records the health pushed by an agent, valid for ttl or the configured TTL when zero.
+// It returns the expiry time of the report.
+func (h *PushHealthCheck) Report(healthy bool, message string, ttl time.Duration) (time.Time, error) {
+	if ttl <= 0 {
+		var err error
+		if ttl, err = time.ParseDuration(h.TTL); err != nil {
+			return time.Time{}, fmt.Errorf("invalid ttl format: %w", err)
+		}
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.healthy = healthy
+	h.message = message
+	h.expires = time.Now().Add(ttl)
+	h.reported = true
+	return h.expires, nil
+}
+
+// restore applies a report received by a previous configuration, unless the
+// check has a more recent report. It reports whether the report was applied.
+func (h *PushHealthCheck) restore(report pushReport) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.reported && !report.expires.After(h.expires) {
+		return false
+	}
+	h.healthy = report.healthy
+	h.message = report.message
+	h.expires = report.expires
+	h.reported = true
+	return true
+}
+
+// onExpiry calls fn when the last report expires, unless another report is received before.
+func (h *PushHealthCheck) onExpiry(fn func()) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.expiryTimer != nil {
+		h.expiryTimer.Stop()
+	}
+	h.expiryTimer = time.AfterFunc(time.Until(h.expires), fn)
+}
+
+// PerformCheck returns the last reported health, a backend without a valid report is unhealthy.
+func (h *PushHealthCheck) PerformCheck(backend *Backend, fqdn string, maxRetries int) bool {
+	typeStr := h.GetType()
+	address := backend.Address
+	start := time.Now()
+	result := false
+	defer func() {
+		ObserveHealthcheck(fqdn, typeStr, address, start, result)
+	}()
+
+	h.mutex.Lock()
+	healthy, message, expires, reported := h.healthy, h.message, h.expires, h.reported
+	h.mutex.Unlock()
+
+	if message != "" {
+		backend.SetCheckDetail(typeStr, message)
+	}
+	switch {
+	case !reported:
+		log.Debugf("[%s] push healthcheck failed: [backend=%s] no report received", fqdn, address)
+		backend.ReportFailure(typeStr, "other", errors.New("no report received"))
+		return false
+	case !time.Now().Before(expires):
+		log.Debugf("[%s] push healthcheck failed: [backend=%s] last report expired at %s", fqdn, address, expires.Format(time.RFC3339))
+		backend.ReportFailure(typeStr, "timeout", fmt.Errorf("last report expired at %s", expires.Format(time.RFC3339)))
+		return false
+	case !healthy:
+		log.Debugf("[%s] push healthcheck failed: [backend=%s] reported unhealthy", fqdn, address)
+		backend.ReportFailure(typeStr, "protocol", errors.New("reported unhealthy"))
+		return false
+	}
+	log.Debugf("[%s] push healthcheck success [backend=%s]", fqdn, address)
+	result = true
+	return true
+}
+
+// Equals compares two PushHealthCheck objects for equality.
+func (h *PushHealthCheck) Equals(other GenericHealthCheck) bool {
+	otherPush, ok := other.(*PushHealthCheck)
+	if !ok {
+		return false
+	}
+	return h.TTL == otherPush.TTL && h.Token == otherPush.Token
+}
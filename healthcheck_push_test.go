@@ -0,0 +1,52 @@
+package gslb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPushHealthCheck_PerformCheck(t *testing.T) {
+	backend := &Backend{Address: "10.0.0.1"}
+	hc := &PushHealthCheck{TTL: "1m"}
+
+	// No report received yet
+	assert.False(t, hc.PerformCheck(backend, "worker.example.com.", 0))
+
+	expires, err := hc.Report(true, "queue empty", 0)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), expires, time.Second)
+	assert.True(t, hc.PerformCheck(backend, "worker.example.com.", 0))
+	assert.Equal(t, "queue empty", backend.GetCheckDetails()["push"])
+
+	_, err = hc.Report(false, "", 0)
+	assert.NoError(t, err)
+	assert.False(t, hc.PerformCheck(backend, "worker.example.com.", 0))
+
+	// The report expires after its own TTL
+	_, err = hc.Report(true, "", 50*time.Millisecond)
+	assert.NoError(t, err)
+	assert.True(t, hc.PerformCheck(backend, "worker.example.com.", 0))
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, hc.PerformCheck(backend, "worker.example.com.", 0))
+}
+
+func TestPushHealthCheck_InvalidTTL(t *testing.T) {
+	hc := &PushHealthCheck{TTL: "soon"}
+	_, err := hc.Report(true, "", 0)
+	assert.Error(t, err)
+
+	_, err = (&HealthCheck{Type: "push", Params: map[string]interface{}{"ttl": "soon"}}).ToSpecificHealthCheck()
+	assert.Error(t, err)
+}
+
+func TestPushHealthCheck_Equals(t *testing.T) {
+	hc1 := &PushHealthCheck{TTL: "30s", Token: "secret"}
+	hc2 := &PushHealthCheck{TTL: "30s", Token: "secret"}
+	hc3 := &PushHealthCheck{TTL: "30s", Token: "other"}
+
+	assert.True(t, hc1.Equals(hc2))
+	assert.False(t, hc1.Equals(hc3))
+	assert.False(t, hc1.Equals(&MockHealthCheck{}))
+}
@@ -0,0 +1,188 @@
+package gslb
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// registryEntryTTL is how long an unused probe result is kept, e.g. after its
+// backends were removed by a configuration reload.
+const registryEntryTTL = 10 * time.Minute
+
+// sharedHealthChecks is the global registry of probes shared between records.
+var sharedHealthChecks = newHealthCheckRegistry()
+
+// sharedResult is the outcome of a probe, replayed on every backend using it.
+type sharedResult struct {
+	alive      bool
+	warning    bool
+	details    map[string]string
+	started    time.Time
+	duration   time.Duration
+	reason     string
+	err        string
+	certExpiry time.Time // Expiry of the certificate reported by the probe, zero if none
+}
+
+// registryEntry holds the last result of one unique probe. Its mutex is held
+// while the probe runs, so concurrent callers wait for the same run.
+type registryEntry struct {
+	mutex    sync.Mutex
+	started  time.Time
+	result   sharedResult
+	lastUsed time.Time
+}
+
+// healthCheckRegistry deduplicates health checks keyed by backend address and
+// check definition, so a probe used by many records runs once per interval.
+type healthCheckRegistry struct {
+	mutex     sync.Mutex
+	entries   map[string]*registryEntry
+	lastPrune time.Time
+}
+
+func newHealthCheckRegistry() *healthCheckRegistry {
+	return &healthCheckRegistry{entries: make(map[string]*registryEntry)}
+}
+
+// entry returns the entry for the key, creating it if needed, and drops the unused ones.
+func (r *healthCheckRegistry) entry(key string) *registryEntry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	if now.Sub(r.lastPrune) > registryEntryTTL/10 {
+		r.lastPrune = now
+		for k, e := range r.entries {
+			if e.mutex.TryLock() {
+				if now.Sub(e.lastUsed) > registryEntryTTL {
+					delete(r.entries, k)
+				}
+				e.mutex.Unlock()
+			}
+		}
+	}
+
+	e, found := r.entries[key]
+	if !found {
+		e = &registryEntry{}
+		r.entries[key] = e
+	}
+	return e
+}
+
+// perform returns the last result of the probe if it started within the
+// caller's interval, or runs it. Results are reused while younger than 90%
+// of the interval, so small scheduling drift does not skip a probe.
+func (r *healthCheckRegistry) perform(key string, interval time.Duration, run func() sharedResult) (sharedResult, bool) {
+	e := r.entry(key)
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.lastUsed = time.Now()
+	if !e.started.IsZero() && time.Since(e.started) < interval*9/10 {
+		return e.result, true
+	}
+	e.started = time.Now()
+	e.result = run()
+	return e.result, false
+}
+
+// size returns the number of unique probes in the registry.
+func (r *healthCheckRegistry) size() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.entries)
+}
+
+// sharedCheckKey returns the registry key of a health check for the backend,
+// run with the given retries and timeout. Checks whose result depends on the
+// record (exec commands and Lua scripts receive the FQDN, Prometheus selectors
+// may use {fqdn}) are only shared within a record. Unknown check types are not
+// shared.
+func sharedCheckKey(backend *Backend, hc GenericHealthCheck, retries int, timeout time.Duration) (string, bool) {
+	scope := ""
+	switch h := hc.(type) {
+	case *HTTPHealthCheck, *TCPHealthCheck, *UDPHealthCheck, *ICMPHealthCheck, *MySQLHealthCheck,
+		*PostgresHealthCheck, *GRPCHealthCheck, *TLSHealthCheck:
+	case *PrometheusHealthCheck:
+		if strings.Contains(h.Metric, "{fqdn}") || strings.Contains(h.Query, "{fqdn}") {
+			scope = backend.Fqdn
+		}
+	case *ExecHealthCheck, *LuaHealthCheck:
+		scope = backend.Fqdn
+	default:
+		return "", false
+	}
+	definition, err := yaml.Marshal(hc)
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%s|%s|%d|%s|%T|%s", backend.Address, scope, retries, timeout, hc, definition), true
+}
+
+// performSharedCheck runs the health check through the registry and applies
+// the details of the result to the backend. The metrics labelled with the
+// record are updated for the records reusing the result as well.
+func (b *Backend) performSharedCheck(hc GenericHealthCheck, maxRetries int, timeout, interval time.Duration) sharedResult {
+	key, ok := sharedCheckKey(b, hc, maxRetries, timeout)
+	if !ok {
+		return b.runProbe(hc, maxRetries)
+	}
+
+	result, reused := sharedHealthChecks.perform(key, interval, func() sharedResult {
+		return b.runProbe(hc, maxRetries)
+	})
+	if reused {
+		log.Debugf("[%s] reusing shared health check result [backend=%s check=%s alive=%v]", b.Fqdn, b.Address, hc.GetType(), result.alive)
+		IncHealthcheckShared(hc.GetType(), b.Address)
+		status := "fail"
+		if result.alive {
+			status = "success"
+		}
+		IncHealthcheckTotal(b.Fqdn, hc.GetType(), b.Address, status)
+		if !result.certExpiry.IsZero() {
+			SetBackendCertificateExpiry(b.Fqdn, b.Address, hc.GetType(), float64(result.certExpiry.Unix()))
+		}
+	}
+
+	for checkType, detail := range result.details {
+		b.SetCheckDetail(checkType, detail)
+	}
+	return result
+}
+
+// runProbe runs a health check against a copy of the backend, so warnings,
+// details and failures can be replayed on every backend using the result.
+func (b *Backend) runProbe(hc GenericHealthCheck, maxRetries int) sharedResult {
+	probe := &Backend{
+		Fqdn:        b.Fqdn,
+		Description: b.Description,
+		Address:     b.Address,
+		Priority:    b.Priority,
+		Weight:      b.Weight,
+		Enable:      b.Enable,
+		Tags:        b.Tags,
+		Timeout:     b.Timeout,
+		Country:     b.Country,
+		City:        b.City,
+		ASN:         b.ASN,
+		Location:    b.Location,
+	}
+	start := time.Now()
+	alive := hc.PerformCheck(probe, b.Fqdn, maxRetries)
+	return sharedResult{
+		alive:      alive,
+		warning:    probe.warningReported,
+		details:    probe.GetCheckDetails(),
+		started:    start,
+		duration:   time.Since(start),
+		reason:     probe.failureReason,
+		err:        probe.failureError,
+		certExpiry: probe.certExpiry,
+	}
+}
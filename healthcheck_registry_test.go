@@ -0,0 +1,143 @@
+package gslb
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthCheckRegistry_SharedAcrossRecords(t *testing.T) {
+	var probes int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&probes, 1)
+		w.WriteHeader(200)
+	}))
+	defer server.Close()
+
+	newBackend := func(fqdn string) *Backend {
+		return &Backend{
+			Fqdn:    fqdn,
+			Address: "127.0.0.1",
+			Enable:  true,
+			HealthChecks: []GenericHealthCheck{&HTTPHealthCheck{
+				Port:         server.Listener.Addr().(*net.TCPAddr).Port,
+				URI:          "/health",
+				Method:       "GET",
+				Timeout:      "2s",
+				ExpectedCode: 200,
+			}},
+		}
+	}
+
+	backends := []*Backend{newBackend("a.example.com."), newBackend("b.example.com."), newBackend("c.example.com.")}
+	var wg sync.WaitGroup
+	for _, backend := range backends {
+		wg.Add(1)
+		go func(backend *Backend) {
+			defer wg.Done()
+			backend.runHealthChecks(scrapeSettings{timeout: 2 * time.Second, interval: 10 * time.Second})
+		}(backend)
+	}
+	wg.Wait()
+
+	for _, backend := range backends {
+		assert.True(t, backend.IsHealthy())
+		// The metrics of the records reusing the result are updated as well
+		hc := backend.HealthChecks[0]
+		assert.Equal(t, 1.0, testutil.ToFloat64(healthcheckTotal.WithLabelValues(backend.Fqdn, hc.GetType(), "127.0.0.1", "success")))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&probes))
+
+	// A caller with a shorter interval probes again
+	newBackend("d.example.com.").runHealthChecks(scrapeSettings{timeout: 2 * time.Second})
+	assert.Equal(t, int32(2), atomic.LoadInt32(&probes))
+
+	// So does a caller with other retries or another timeout
+	newBackend("e.example.com.").runHealthChecks(scrapeSettings{retries: 3, timeout: 2 * time.Second, interval: 10 * time.Second})
+	assert.Equal(t, int32(3), atomic.LoadInt32(&probes))
+	newBackend("f.example.com.").runHealthChecks(scrapeSettings{timeout: 3 * time.Second, interval: 10 * time.Second})
+	assert.Equal(t, int32(4), atomic.LoadInt32(&probes))
+}
+
+func TestHealthCheckRegistry_Perform(t *testing.T) {
+	registry := newHealthCheckRegistry()
+	runs := 0
+	run := func() sharedResult {
+		runs++
+		return sharedResult{alive: runs%2 == 1}
+	}
+
+	result, reused := registry.perform("key", time.Minute, run)
+	assert.True(t, result.alive)
+	assert.False(t, reused)
+
+	result, reused = registry.perform("key", time.Minute, run)
+	assert.True(t, result.alive)
+	assert.True(t, reused)
+
+	result, reused = registry.perform("key", 0, run)
+	assert.False(t, result.alive)
+	assert.False(t, reused)
+
+	registry.perform("other", time.Minute, run)
+	assert.Equal(t, 3, runs)
+	assert.Equal(t, 2, registry.size())
+}
+
+func TestHealthCheckRegistry_Key(t *testing.T) {
+	tcp := &TCPHealthCheck{Port: 443, Timeout: "5s"}
+	a := &Backend{Fqdn: "a.example.com.", Address: "10.0.0.1"}
+	b := &Backend{Fqdn: "b.example.com.", Address: "10.0.0.1"}
+	c := &Backend{Fqdn: "a.example.com.", Address: "10.0.0.2"}
+
+	keyA, ok := sharedCheckKey(a, tcp, 1, 5*time.Second)
+	assert.True(t, ok)
+	keyB, _ := sharedCheckKey(b, &TCPHealthCheck{Port: 443, Timeout: "5s"}, 1, 5*time.Second)
+	keyC, _ := sharedCheckKey(c, tcp, 1, 5*time.Second)
+	keyOtherPort, _ := sharedCheckKey(a, &TCPHealthCheck{Port: 80, Timeout: "5s"}, 1, 5*time.Second)
+	assert.Equal(t, keyA, keyB)
+	assert.NotEqual(t, keyA, keyC)
+	assert.NotEqual(t, keyA, keyOtherPort)
+	keyOtherRetries, _ := sharedCheckKey(a, tcp, 0, 5*time.Second)
+	keyOtherTimeout, _ := sharedCheckKey(a, tcp, 1, 10*time.Second)
+	assert.NotEqual(t, keyA, keyOtherRetries)
+	assert.NotEqual(t, keyA, keyOtherTimeout)
+
+	// Record dependent checks are only shared within a record
+	exec := &ExecHealthCheck{Command: "/bin/true", Timeout: "1s"}
+	execA, _ := sharedCheckKey(a, exec, 1, 5*time.Second)
+	execB, _ := sharedCheckKey(b, exec, 1, 5*time.Second)
+	assert.NotEqual(t, execA, execB)
+
+	_, ok = sharedCheckKey(a, &MockHealthCheck{}, 1, 5*time.Second)
+	assert.False(t, ok)
+}
+
+func TestHealthCheckRegistry_ReplaysWarnings(t *testing.T) {
+	newBackend := func() *Backend {
+		return &Backend{
+			Fqdn:    "app.example.com.",
+			Address: "10.0.0.3",
+			Enable:  true,
+			HealthChecks: []GenericHealthCheck{
+				&ExecHealthCheck{Command: "/bin/sh", Args: []string{"-c", "echo WARNING; exit 1"}, Timeout: "1s", WarningIsDegraded: true},
+			},
+		}
+	}
+	first, second := newBackend(), newBackend()
+	first.runHealthChecks(scrapeSettings{timeout: 2 * time.Second, interval: time.Minute})
+	second.runHealthChecks(scrapeSettings{timeout: 2 * time.Second, interval: time.Minute})
+
+	for _, backend := range []*Backend{first, second} {
+		assert.True(t, backend.Alive)
+		assert.True(t, backend.Degraded)
+		assert.Equal(t, "WARNING", backend.GetCheckDetails()["exec"])
+	}
+}
@@ -0,0 +1,106 @@
+package gslb
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/quic-go/quic-go"
+)
+
+// SourceBinding selects where the probes of a health check leave the host from,
+// e.g. to use the same interface as the production traffic on multi-homed hosts.
+// Empty fields inherit the global settings of the Corefile.
+type SourceBinding struct {
+	SourceAddress string `yaml:"source_address"` // Local IP address of the probes
+	Interface     string `yaml:"interface"`      // Network interface the probes are bound to (Linux only)
+	Mark          int    `yaml:"so_mark"`        // SO_MARK set on the probe sockets (Linux only)
+}
+
+// inherit returns the binding with its empty fields taken from the global settings.
+func (s SourceBinding) inherit(global SourceBinding) SourceBinding {
+	if s.SourceAddress == "" {
+		s.SourceAddress = global.SourceAddress
+	}
+	if s.Interface == "" {
+		s.Interface = global.Interface
+	}
+	if s.Mark == 0 {
+		s.Mark = global.Mark
+	}
+	return s
+}
+
+// inheritSource resolves the global settings into the binding of a health check,
+// when the configuration is loaded. The probes use the binding of their check only.
+func (s *SourceBinding) inheritSource(global SourceBinding) {
+	*s = s.inherit(global)
+}
+
+// sourceBoundCheck is a health check with a source binding.
+type sourceBoundCheck interface {
+	inheritSource(global SourceBinding)
+}
+
+// validate checks the format of the binding.
+func (s SourceBinding) validate() error {
+	if s.SourceAddress != "" && net.ParseIP(s.SourceAddress) == nil {
+		return fmt.Errorf("invalid source_address '%s', expected an IP address", s.SourceAddress)
+	}
+	if s.Mark < 0 {
+		return fmt.Errorf("invalid so_mark %d, expected a positive number", s.Mark)
+	}
+	return nil
+}
+
+// dialer returns a TCP dialer with the given timeout, bound to the source.
+func (s SourceBinding) dialer(timeout time.Duration) (*net.Dialer, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	if s.SourceAddress != "" {
+		dialer.LocalAddr = &net.TCPAddr{IP: net.ParseIP(s.SourceAddress)}
+	}
+	if s.Interface != "" || s.Mark != 0 {
+		control, err := s.control()
+		if err != nil {
+			return nil, err
+		}
+		dialer.Control = control
+	}
+	return dialer, nil
+}
+
+// dialQUIC opens a QUIC connection from a UDP socket bound to the source,
+// the socket is closed with the connection.
+func (s SourceBinding) dialQUIC(dialer *net.Dialer) func(context.Context, string, *tls.Config, *quic.Config) (quic.EarlyConnection, error) {
+	return func(ctx context.Context, addr string, tlsConfig *tls.Config, quicConfig *quic.Config) (quic.EarlyConnection, error) {
+		udpAddr, err := net.ResolveUDPAddr("udp", addr)
+		if err != nil {
+			return nil, err
+		}
+		listenConfig := net.ListenConfig{Control: dialer.Control}
+		pc, err := listenConfig.ListenPacket(ctx, "udp", net.JoinHostPort(s.SourceAddress, "0"))
+		if err != nil {
+			return nil, err
+		}
+		conn, err := quic.DialEarly(ctx, pc, udpAddr, tlsConfig, quicConfig)
+		if err != nil {
+			pc.Close()
+			return nil, err
+		}
+		go func() {
+			<-conn.Context().Done()
+			pc.Close()
+		}()
+		return conn, nil
+	}
+}
+
+// isSet reports whether the probes are bound in any way.
+func (s SourceBinding) isSet() bool {
+	return s != SourceBinding{}
+}
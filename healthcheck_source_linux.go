@@ -0,0 +1,27 @@
+//go:build linux
+
+package gslb
+
+import "syscall"
+
+// control returns the socket control function binding the probe sockets to the
+// interface and setting their mark.
+func (s SourceBinding) control() (func(network, address string, c syscall.RawConn) error, error) {
+	return func(network, address string, c syscall.RawConn) error {
+		var sockErr error
+		err := c.Control(func(fd uintptr) {
+			if s.Interface != "" {
+				if sockErr = syscall.BindToDevice(int(fd), s.Interface); sockErr != nil {
+					return
+				}
+			}
+			if s.Mark != 0 {
+				sockErr = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_MARK, s.Mark)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		return sockErr
+	}, nil
+}
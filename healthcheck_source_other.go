@@ -0,0 +1,13 @@
+//go:build !linux
+
+package gslb
+
+import (
+	"errors"
+	"syscall"
+)
+
+// control is not supported, interface binding and SO_MARK are Linux only.
+func (s SourceBinding) control() (func(network, address string, c syscall.RawConn) error, error) {
+	return nil, errors.New("interface and so_mark are only supported on Linux")
+}
@@ -0,0 +1,137 @@
+package gslb
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceBinding_Inherit(t *testing.T) {
+	global := SourceBinding{SourceAddress: "10.0.0.1", Interface: "eth1", Mark: 7}
+
+	assert.Equal(t, global, SourceBinding{}.inherit(global))
+	assert.Equal(t, SourceBinding{SourceAddress: "10.0.0.2", Interface: "eth1", Mark: 7},
+		SourceBinding{SourceAddress: "10.0.0.2"}.inherit(global))
+}
+
+func TestSourceBinding_ResolvedOnLoad(t *testing.T) {
+	zone := writeTempYAML(t, `
+records:
+  app.example.com.:
+    backends:
+      - address: 10.0.0.1
+        healthchecks:
+          - type: tcp
+            params:
+              port: 80
+          - type: http
+            params:
+              port: 80
+              source_address: 10.0.0.2
+      - address: lb.example.com
+        healthchecks:
+          - type: tcp
+            params:
+              port: 80
+`)
+	g := &GSLB{HealthcheckSource: SourceBinding{SourceAddress: "10.0.0.1", Mark: 7}}
+	require.NoError(t, loadConfigFile(g, zone, "example.com."))
+	record := g.Records["example.com."]["app.example.com."]
+
+	checks := record.Backends[0].GetHealthChecks()
+	assert.Equal(t, SourceBinding{SourceAddress: "10.0.0.1", Mark: 7}, checks[0].(*TCPHealthCheck).SourceBinding)
+	assert.Equal(t, SourceBinding{SourceAddress: "10.0.0.2", Mark: 7}, checks[1].(*HTTPHealthCheck).SourceBinding)
+
+	// The backends resolved from a hostname inherit the binding as well
+	record.mutex.Lock()
+	record.applyHostnameAddresses(record.hostnames[0], []string{"192.0.2.10"})
+	record.mutex.Unlock()
+	resolved := record.Backends[1].GetHealthChecks()[0].(*TCPHealthCheck)
+	assert.Equal(t, SourceBinding{SourceAddress: "10.0.0.1", Mark: 7}, resolved.SourceBinding)
+}
+
+func TestSourceBinding_Dialer(t *testing.T) {
+	dialer, err := SourceBinding{}.dialer(time.Second)
+	require.NoError(t, err)
+	assert.Nil(t, dialer.LocalAddr)
+	assert.Nil(t, dialer.Control)
+
+	dialer, err = SourceBinding{SourceAddress: "127.0.0.1"}.dialer(time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, &net.TCPAddr{IP: net.ParseIP("127.0.0.1")}, dialer.LocalAddr)
+
+	_, err = SourceBinding{SourceAddress: "eth0"}.dialer(time.Second)
+	assert.Error(t, err)
+	_, err = SourceBinding{Mark: -1}.dialer(time.Second)
+	assert.Error(t, err)
+}
+
+func TestSourceBinding_Params(t *testing.T) {
+	hc := &HealthCheck{Type: "tcp", Params: map[string]interface{}{
+		"port":           8080,
+		"source_address": "192.0.2.10",
+		"interface":      "eth1",
+		"so_mark":        100,
+	}}
+	specific, err := hc.ToSpecificHealthCheck()
+	require.NoError(t, err)
+	tcp := specific.(*TCPHealthCheck)
+	assert.Equal(t, SourceBinding{SourceAddress: "192.0.2.10", Interface: "eth1", Mark: 100}, tcp.SourceBinding)
+
+	other := *tcp
+	other.Mark = 0
+	assert.False(t, tcp.Equals(&other))
+}
+
+func TestTCPHealthCheck_SourceAddress(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	remote := make(chan net.Addr, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			remote <- conn.RemoteAddr()
+			conn.Close()
+		}
+	}()
+
+	port := ln.Addr().(*net.TCPAddr).Port
+	hc := &TCPHealthCheck{Port: port, Timeout: "1s", SourceBinding: SourceBinding{SourceAddress: "127.0.0.1"}}
+	assert.True(t, hc.PerformCheck(&Backend{Address: "127.0.0.1"}, "example.com.", 0))
+	assert.Equal(t, "127.0.0.1", (<-remote).(*net.TCPAddr).IP.String())
+
+	// Probes cannot leave from an address which is not local
+	hc.SourceAddress = "192.0.2.1"
+	assert.False(t, hc.PerformCheck(&Backend{Address: "127.0.0.1"}, "example.com.", 0))
+}
+
+func TestHTTPHealthCheck_SourceAddress(t *testing.T) {
+	remote := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		remote <- host
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+
+	hc := &HTTPHealthCheck{}
+	hc.SetDefault()
+	hc.Port = portNum
+	hc.EnableTLS = false
+	hc.inheritSource(SourceBinding{SourceAddress: "127.0.0.1"})
+	assert.True(t, hc.PerformCheck(&Backend{Address: "127.0.0.1"}, "example.com.", 0))
+	assert.Equal(t, "127.0.0.1", <-remote)
+
+	// The source of the check replaces the global one
+	hc.SourceAddress = "192.0.2.1"
+	hc.inheritSource(SourceBinding{SourceAddress: "127.0.0.1"})
+	assert.False(t, hc.PerformCheck(&Backend{Address: "127.0.0.1"}, "example.com.", 0))
+}
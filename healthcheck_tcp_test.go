@@ -2,6 +2,8 @@ package gslb
 
 import (
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -173,3 +175,92 @@ func TestTCPHealthCheck_Equals(t *testing.T) {
 	// Assert that hc1 and hc3 are not equal
 	assert.False(t, hc1.Equals(hc3))
 }
+
+func TestTCPHealthCheck_SendExpect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find free port: %v", err)
+	}
+	defer ln.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	// Minimal Redis-like server answering PING with +PONG
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(c net.Conn) {
+				defer c.Close()
+				buf := make([]byte, 64)
+				n, _ := c.Read(buf)
+				if string(buf[:n]) == "PING\r\n" {
+					c.Write([]byte("+PONG\r\n"))
+				} else {
+					c.Write([]byte("-ERR unknown command\r\n"))
+				}
+			}(conn)
+		}
+	}()
+
+	backend := &Backend{Address: "127.0.0.1"}
+
+	tests := []struct {
+		name     string
+		hc       *TCPHealthCheck
+		expected bool
+	}{
+		{"RegexMatch", &TCPHealthCheck{Port: port, Timeout: "1s", Send: `PING\r\n`, Expect: `^\+PONG`}, true},
+		{"HexPayloadAndPattern", &TCPHealthCheck{Port: port, Timeout: "1s", SendHex: "50494e470d0a", ExpectHex: "2b504f4e47"}, true},
+		{"RegexMismatch", &TCPHealthCheck{Port: port, Timeout: "1s", Send: `QUIT\r\n`, Expect: `^\+PONG`}, false},
+		{"InvalidRegex", &TCPHealthCheck{Port: port, Timeout: "1s", Send: `PING\r\n`, Expect: `(`}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.hc.PerformCheck(backend, "example.com", 0))
+		})
+	}
+}
+
+func TestTCPHealthCheck_TLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer server.Close()
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+
+	hc := &TCPHealthCheck{
+		Port:          port,
+		Timeout:       "1s",
+		Send:          `GET / HTTP/1.0\r\n\r\n`,
+		Expect:        `^HTTP/1\.[01] 200`,
+		EnableTLS:     true,
+		SkipTLSVerify: true,
+	}
+	assert.True(t, hc.PerformCheck(&Backend{Address: "127.0.0.1"}, "example.com", 0))
+
+	hc.SkipTLSVerify = false
+	assert.False(t, hc.PerformCheck(&Backend{Address: "127.0.0.1"}, "example.com", 0))
+}
+
+func TestDecodePayload(t *testing.T) {
+	b, err := decodePayload(`stats\r\n`, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("stats\r\n"), b)
+
+	b, err = decodePayload("PING\r\n", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("PING\r\n"), b)
+
+	b, err = decodePayload(`\x00\xff`, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0xff}, b)
+
+	b, err = decodePayload("", "00 ff")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0xff}, b)
+
+	_, err = decodePayload("", "zz")
+	assert.Error(t, err)
+}
@@ -22,7 +22,7 @@ func TestToSpecificHealthCheck_Unsupported(t *testing.T) {
 
 // Test that all known healthcheck types are handled in ToSpecificHealthCheck
 func TestToSpecificHealthCheck_AllTypesHandled(t *testing.T) {
-	types := []string{"http", ICMPType, "tcp", "mysql", "grpc", "lua"}
+	types := []string{"http", ICMPType, "tcp", "mysql", "grpc", "lua", "tls", "udp", "postgres", "exec", "prometheus", "push"}
 	for _, typ := range types {
 		hc := &HealthCheck{
 			Type:   typ,
@@ -34,7 +34,7 @@ func TestToSpecificHealthCheck_AllTypesHandled(t *testing.T) {
 }
 
 func TestToSpecificHealthCheck_AllKnownTypes(t *testing.T) {
-	types := []string{"http", ICMPType, "tcp", "mysql", "grpc", "lua"}
+	types := []string{"http", ICMPType, "tcp", "mysql", "grpc", "lua", "tls", "udp", "postgres", "exec", "prometheus", "push"}
 	for _, typ := range types {
 		hc := &HealthCheck{
 			Type:   typ,
@@ -64,13 +64,37 @@ func TestHealthCheck_GetType(t *testing.T) {
 	mysqlHC := &MySQLHealthCheck{}
 	assert.Equal(t, "mysql/0", mysqlHC.GetType())
 
+	// Test PostgreSQL health check
+	postgresHC := &PostgresHealthCheck{}
+	assert.Equal(t, "postgres/0", postgresHC.GetType())
+
 	// Test gRPC health check
 	grpcHC := &GRPCHealthCheck{}
-	assert.Equal(t, "grpc", grpcHC.GetType())
+	assert.Equal(t, "grpc/0", grpcHC.GetType())
 
 	// Test Lua health check
 	luaHC := &LuaHealthCheck{}
 	assert.Equal(t, "lua", luaHC.GetType())
+
+	// Test TLS health check
+	tlsHC := &TLSHealthCheck{}
+	assert.Equal(t, "tls/0", tlsHC.GetType())
+
+	// Test UDP health check
+	udpHC := &UDPHealthCheck{}
+	assert.Equal(t, "udp/0", udpHC.GetType())
+
+	// Test exec health check
+	execHC := &ExecHealthCheck{}
+	assert.Equal(t, "exec", execHC.GetType())
+
+	// Test Prometheus health check
+	prometheusHC := &PrometheusHealthCheck{}
+	assert.Equal(t, "prometheus", prometheusHC.GetType())
+
+	// Test push health check
+	pushHC := &PushHealthCheck{}
+	assert.Equal(t, "push", pushHC.GetType())
 }
 
 func TestHealthChecksEqual(t *testing.T) {
@@ -0,0 +1,218 @@
+package gslb
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/creasty/defaults"
+)
+
+// TLSHealthCheck validates the certificate presented by a backend.
+type TLSHealthCheck struct {
+	Port         int    `yaml:"port" default:"443"`              // TLS port to connect to
+	ServerName   string `yaml:"server_name" default:""`          // SNI and expected hostname (default: backend address)
+	CAFile       string `yaml:"ca_file" default:""`              // PEM bundle used instead of the system roots
+	Timeout      string `yaml:"timeout" default:"5s"`            // Timeout for the TLS handshake
+	MinDaysValid int    `yaml:"min_days_valid" default:"7"`      // Minimum number of days before expiry
+	WarnOnly     bool   `yaml:"warn_only" default:"false"`       // Only log a warning when below min_days_valid
+	SkipVerify   bool   `yaml:"skip_tls_verify" default:"false"` // Skip chain and hostname validation
+}
+
+// SetDefault applies default values to TLSHealthCheck fields.
+func (h *TLSHealthCheck) SetDefault() {
+	defaults.Set(h)
+}
+
+// GetType returns the type of the health check as a string.
+func (h *TLSHealthCheck) GetType() string {
+	return fmt.Sprintf("tls/%d", h.Port)
+}
+
+// PerformCheck handshakes with the backend and validates its certificate.
+func (h *TLSHealthCheck) PerformCheck(backend *Backend, fqdn string, maxRetries int) bool {
+	typeStr := h.GetType()
+	address := backend.Address
+	start := time.Now()
+	result := false
+	defer func() {
+		ObserveHealthcheck(fqdn, typeStr, address, start, result)
+	}()
+
+	timeout, err := time.ParseDuration(h.Timeout)
+	if err != nil {
+		log.Errorf("[%s] invalid timeout format: %v", fqdn, err)
+		backend.ReportFailure(typeStr, "timeout", err)
+		return false
+	}
+
+	roots, err := loadCertPool(h.CAFile)
+	if err != nil {
+		log.Errorf("[%s] TLS health check: %v", fqdn, err)
+		backend.ReportFailure(typeStr, "other", err)
+		return false
+	}
+
+	serverName := h.ServerName
+	if serverName == "" {
+		serverName = backend.Address
+	}
+
+	addressPort := net.JoinHostPort(backend.Address, strconv.Itoa(h.Port))
+	for retry := 0; retry <= maxRetries; retry++ {
+		log.Debugf("[%s] Attempting TLS health check on %s (sni=%s)", fqdn, addressPort, serverName)
+
+		certs, err := fetchPeerCertificates(addressPort, serverName, timeout)
+		if err != nil {
+			log.Debugf("[%s] TLS health check failed (retries=%d/%d): %v", fqdn, retry, maxRetries, err)
+			if retry == maxRetries {
+				backend.ReportFailure(typeStr, "connection", err)
+				return false
+			}
+			continue
+		}
+
+		leaf := certs[0]
+		backend.ReportCertificateExpiry(fqdn, typeStr, leaf.NotAfter)
+
+		if err := h.verifyCertificate(fqdn, certs, serverName, roots, time.Now()); err != nil {
+			log.Debugf("[%s] TLS health check failed for %s: %v", fqdn, addressPort, err)
+			backend.ReportFailure(typeStr, "protocol", err)
+			return false
+		}
+
+		result = true
+		return true
+	}
+
+	backend.ReportFailure(typeStr, "other", nil)
+	return false
+}
+
+// verifyCertificate checks the chain, the hostname and the remaining validity of the peer certificates.
+func (h *TLSHealthCheck) verifyCertificate(fqdn string, certs []*x509.Certificate, serverName string, roots *x509.CertPool, now time.Time) error {
+	leaf := certs[0]
+
+	if !h.SkipVerify {
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+		if _, err := leaf.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			CurrentTime:   now,
+		}); err != nil {
+			return fmt.Errorf("certificate chain invalid: %w", err)
+		}
+		if err := leaf.VerifyHostname(serverName); err != nil {
+			return fmt.Errorf("certificate hostname mismatch: %w", err)
+		}
+	}
+
+	remaining := leaf.NotAfter.Sub(now)
+	if remaining < time.Duration(h.MinDaysValid)*24*time.Hour {
+		if h.WarnOnly {
+			log.Warningf("[%s] certificate for %s expires in %d days (%s)", fqdn, serverName, int(remaining.Hours()/24), leaf.NotAfter.Format(time.RFC3339))
+			return nil
+		}
+		return fmt.Errorf("certificate expires in %d days (%s), minimum is %d", int(remaining.Hours()/24), leaf.NotAfter.Format(time.RFC3339), h.MinDaysValid)
+	}
+	return nil
+}
+
+// fetchPeerCertificates performs a TLS handshake and returns the certificates presented by the peer.
+// Verification is done afterwards so that an invalid certificate can still be inspected.
+func fetchPeerCertificates(addressPort, serverName string, timeout time.Duration) ([]*x509.Certificate, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", addressPort, &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificate presented by %s", addressPort)
+	}
+	return certs, nil
+}
+
+// loadCertPool reads a PEM bundle, or returns nil to use the system roots when path is empty.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no valid certificate found in CA file %s", path)
+	}
+	return pool, nil
+}
+
+// newClientTLSConfig builds the TLS configuration used by healthchecks connecting to a backend.
+// caFile replaces the system roots and certFile/keyFile enable client certificate authentication.
+func newClientTLSConfig(serverName, caFile, certFile, keyFile string, skipVerify bool) (*tls.Config, error) {
+	roots, err := loadCertPool(caFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		ServerName:         serverName,
+		RootCAs:            roots,
+		InsecureSkipVerify: skipVerify,
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// parseTLSVersion converts a version string such as "1.2" to its crypto/tls constant, 0 when empty.
+func parseTLSVersion(version string) (uint16, error) {
+	switch version {
+	case "":
+		return 0, nil
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("invalid TLS version '%s', expected 1.0, 1.1, 1.2 or 1.3", version)
+	}
+}
+
+// Equals compares two TLSHealthCheck objects for equality.
+func (h *TLSHealthCheck) Equals(other GenericHealthCheck) bool {
+	otherTLS, ok := other.(*TLSHealthCheck)
+	if !ok {
+		return false
+	}
+
+	return h.Port == otherTLS.Port &&
+		h.ServerName == otherTLS.ServerName &&
+		h.CAFile == otherTLS.CAFile &&
+		h.Timeout == otherTLS.Timeout &&
+		h.MinDaysValid == otherTLS.MinDaysValid &&
+		h.WarnOnly == otherTLS.WarnOnly &&
+		h.SkipVerify == otherTLS.SkipVerify
+}
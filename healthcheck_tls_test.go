@@ -0,0 +1,173 @@
+package gslb
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTLSTestServer starts an HTTPS server and writes its certificate to a CA file.
+func newTLSTestServer(t *testing.T) (*httptest.Server, int, string) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(server.Close)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, pemData, 0600); err != nil {
+		t.Fatalf("failed to write CA file: %v", err)
+	}
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+	return server, port, caFile
+}
+
+func TestTLSHealthCheck(t *testing.T) {
+	RegisterMetrics()
+	server, port, caFile := newTLSTestServer(t)
+	backend := &Backend{Address: "127.0.0.1"}
+
+	t.Run("Success", func(t *testing.T) {
+		hc := &TLSHealthCheck{Port: port, CAFile: caFile, Timeout: "1s", MinDaysValid: 1}
+		assert.True(t, hc.PerformCheck(backend, "tls.example.com.", 0))
+
+		expiry := testutil.ToFloat64(backendCertificateExpiry.WithLabelValues("tls.example.com.", "127.0.0.1", hc.GetType()))
+		assert.Equal(t, float64(server.Certificate().NotAfter.Unix()), expiry)
+	})
+
+	t.Run("SharedExpiry", func(t *testing.T) {
+		// The expiry is set for the records reusing a shared probe as well
+		hc := &TLSHealthCheck{Port: port, CAFile: caFile, Timeout: "1s", MinDaysValid: 1}
+		settings := scrapeSettings{timeout: 2 * time.Second, interval: time.Minute}
+		for _, fqdn := range []string{"tls-a.example.com.", "tls-b.example.com."} {
+			(&Backend{Fqdn: fqdn, Address: "127.0.0.1", Enable: true, HealthChecks: []GenericHealthCheck{hc}}).runHealthChecks(settings)
+			expiry := testutil.ToFloat64(backendCertificateExpiry.WithLabelValues(fqdn, "127.0.0.1", hc.GetType()))
+			assert.Equal(t, float64(server.Certificate().NotAfter.Unix()), expiry)
+		}
+	})
+
+	t.Run("UntrustedChain", func(t *testing.T) {
+		hc := &TLSHealthCheck{Port: port, Timeout: "1s", MinDaysValid: 1}
+		assert.False(t, hc.PerformCheck(backend, "tls.example.com.", 0))
+	})
+
+	t.Run("HostnameMismatch", func(t *testing.T) {
+		hc := &TLSHealthCheck{Port: port, ServerName: "wrong.example.org", CAFile: caFile, Timeout: "1s", MinDaysValid: 1}
+		assert.False(t, hc.PerformCheck(backend, "tls.example.com.", 0))
+	})
+
+	t.Run("NoServer", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to find free port: %v", err)
+		}
+		closedPort := ln.Addr().(*net.TCPAddr).Port
+		ln.Close()
+		hc := &TLSHealthCheck{Port: closedPort, Timeout: "1s"}
+		assert.False(t, hc.PerformCheck(backend, "tls.example.com.", 0))
+	})
+}
+
+func TestTLSHealthCheck_VerifyExpiry(t *testing.T) {
+	cert := &x509.Certificate{NotAfter: time.Now().Add(3 * 24 * time.Hour)}
+
+	hc := &TLSHealthCheck{MinDaysValid: 7, SkipVerify: true}
+	assert.Error(t, hc.verifyCertificate("tls.example.com.", []*x509.Certificate{cert}, "example.com", nil, time.Now()))
+
+	hc.WarnOnly = true
+	assert.NoError(t, hc.verifyCertificate("tls.example.com.", []*x509.Certificate{cert}, "example.com", nil, time.Now()))
+
+	hc = &TLSHealthCheck{MinDaysValid: 2, SkipVerify: true}
+	assert.NoError(t, hc.verifyCertificate("tls.example.com.", []*x509.Certificate{cert}, "example.com", nil, time.Now()))
+}
+
+func TestTLSHealthCheck_Equals(t *testing.T) {
+	hc1 := &TLSHealthCheck{Port: 443, ServerName: "example.com", Timeout: "5s", MinDaysValid: 7}
+	hc2 := &TLSHealthCheck{Port: 443, ServerName: "example.com", Timeout: "5s", MinDaysValid: 7}
+	hc3 := &TLSHealthCheck{Port: 443, ServerName: "example.com", Timeout: "5s", MinDaysValid: 14}
+
+	assert.True(t, hc1.Equals(hc2))
+	assert.False(t, hc1.Equals(hc3))
+	assert.False(t, hc1.Equals(&TCPHealthCheck{Port: 443}))
+}
+
+// writeClientCertificate generates a self-signed client certificate and writes it as PEM files.
+func writeClientCertificate(t *testing.T) (string, string, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "gslb-healthcheck"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client-key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	return certFile, keyFile, cert
+}
+
+func TestNewClientTLSConfig(t *testing.T) {
+	_, _, caFile := newTLSTestServer(t)
+	certFile, keyFile, _ := writeClientCertificate(t)
+
+	config, err := newClientTLSConfig("example.com", caFile, certFile, keyFile, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", config.ServerName)
+	assert.NotNil(t, config.RootCAs)
+	assert.Len(t, config.Certificates, 1)
+
+	_, err = newClientTLSConfig("", "/nonexistent/ca.pem", "", "", false)
+	assert.Error(t, err)
+
+	_, err = newClientTLSConfig("", "", certFile, "", false)
+	assert.Error(t, err)
+}
+
+func TestParseTLSVersion(t *testing.T) {
+	v, err := parseTLSVersion("1.2")
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), v)
+
+	v, err = parseTLSVersion("")
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0), v)
+
+	_, err = parseTLSVersion("1.4")
+	assert.Error(t, err)
+}
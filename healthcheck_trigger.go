@@ -0,0 +1,107 @@
+package gslb
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// healthcheckFilter selects the backends of an on-demand health check run.
+// All the set fields must match, a backend matches tags when it has one of them.
+type healthcheckFilter struct {
+	Record   string   `json:"record"`
+	Address  string   `json:"address"`
+	Tags     []string `json:"tags"`
+	Location string   `json:"location"`
+}
+
+// empty reports whether no filter is set.
+func (f healthcheckFilter) empty() bool {
+	return f.Record == "" && f.Address == "" && len(f.Tags) == 0 && f.Location == ""
+}
+
+// matches reports whether a backend of a record is selected by the filter.
+func (f healthcheckFilter) matches(fqdn string, backend BackendInterface) bool {
+	if f.Record != "" && dns.Fqdn(strings.ToLower(f.Record)) != fqdn {
+		return false
+	}
+	if f.Address != "" && backend.GetAddress() != f.Address {
+		return false
+	}
+	if f.Location != "" && backend.GetLocation() != f.Location {
+		return false
+	}
+	if len(f.Tags) > 0 && !slices.ContainsFunc(backend.GetTags(), func(tag string) bool { return slices.Contains(f.Tags, tag) }) {
+		return false
+	}
+	return true
+}
+
+// healthcheckRunResult is the outcome of an on-demand health check run on a backend.
+type healthcheckRunResult struct {
+	Record   string         `json:"record"`
+	Address  string         `json:"address"`
+	Alive    bool           `json:"alive"`
+	Degraded bool           `json:"degraded"`
+	Checks   []CheckHistory `json:"checks"` // Result of this run for each health check
+}
+
+// runHealthChecksNow runs the health checks of the selected backends right away,
+// without waiting for their next scheduled run, and returns the results. The
+// checks are queued ahead of the scheduled ones on the healthcheck workers.
+func (g *GSLB) runHealthChecksNow(filter healthcheckFilter) []healthcheckRunResult {
+	var jobs []*healthcheckJob
+	records := make(map[*healthcheckJob]*Record)
+	g.Mutex.RLock()
+	for _, zoneRecords := range g.Records {
+		for fqdn, record := range zoneRecords {
+			record.mutex.RLock()
+			run := &recordRun{settings: g.scrapeSettings(record)}
+			run.settings.force = true
+			for _, be := range record.Backends {
+				backend, ok := be.(*Backend)
+				if ok && filter.matches(fqdn, backend) {
+					backend.SetFqdn(record.Fqdn)
+					log.Debugf("[%s] running on-demand health checks for backend %s", record.Fqdn, backend.Address)
+					job := &healthcheckJob{run: run, backend: backend}
+					jobs = append(jobs, job)
+					records[job] = record
+				}
+			}
+			record.mutex.RUnlock()
+		}
+	}
+	g.Mutex.RUnlock()
+	if len(jobs) == 0 {
+		return []healthcheckRunResult{}
+	}
+	g.scheduler.runNow(jobs).Wait()
+
+	results := make([]healthcheckRunResult, 0, len(jobs))
+	refreshed := make(map[*Record]bool)
+	for _, job := range jobs {
+		record, backend := records[job], job.backend.(*Backend)
+		result := healthcheckRunResult{Record: record.Fqdn, Address: backend.Address, Checks: job.results}
+		if result.Checks == nil {
+			result.Checks = []CheckHistory{}
+		}
+		backend.mutex.RLock()
+		result.Alive, result.Degraded = backend.Alive, backend.Degraded
+		backend.mutex.RUnlock()
+		results = append(results, result)
+		if !refreshed[record] {
+			record.mutex.RLock()
+			record.refreshHealthStatus()
+			record.mutex.RUnlock()
+			refreshed[record] = true
+		}
+	}
+	slices.SortFunc(results, func(a, b healthcheckRunResult) int {
+		if c := strings.Compare(a.Record, b.Record); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Address, b.Address)
+	})
+	return results
+}
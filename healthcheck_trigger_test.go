@@ -0,0 +1,67 @@
+package gslb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthcheckFilter_Matches(t *testing.T) {
+	backend := &Backend{Address: "10.0.0.1", Tags: []string{"prod", "ssd"}, Location: "eu-west-1"}
+	tests := []struct {
+		name   string
+		filter healthcheckFilter
+		want   bool
+	}{
+		{"record", healthcheckFilter{Record: "App.example.com"}, true},
+		{"other record", healthcheckFilter{Record: "web.example.com."}, false},
+		{"address", healthcheckFilter{Address: "10.0.0.1"}, true},
+		{"address prefix", healthcheckFilter{Address: "10.0.0."}, false},
+		{"one of the tags", healthcheckFilter{Tags: []string{"test", "ssd"}}, true},
+		{"no tag", healthcheckFilter{Tags: []string{"test"}}, false},
+		{"location", healthcheckFilter{Location: "eu-west-1"}, true},
+		{"all filters", healthcheckFilter{Record: "app.example.com.", Address: "10.0.0.1", Location: "us-east-1"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.matches("app.example.com.", backend))
+		})
+	}
+	assert.True(t, healthcheckFilter{}.empty())
+}
+
+func TestGSLB_RunHealthChecksNow(t *testing.T) {
+	RegisterMetrics()
+	g, push := newPushTestGSLB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	g.startScheduler(ctx)
+	_, err := push.Report(true, "", 0)
+	assert.NoError(t, err)
+
+	results := g.runHealthChecksNow(healthcheckFilter{Record: "worker.example.com"})
+	assert.Len(t, results, 2)
+	assert.Equal(t, "10.0.0.1", results[0].Address)
+	assert.True(t, results[0].Alive)
+	assert.Len(t, results[0].Checks, 1)
+	assert.Equal(t, "push", results[0].Checks[0].Type)
+	assert.Len(t, results[0].Checks[0].Results, 1)
+	assert.True(t, results[0].Checks[0].Results[0].Success)
+	assert.Equal(t, "10.0.0.2", results[1].Address)
+	assert.True(t, results[1].Alive)
+	assert.Equal(t, float64(2), testutil.ToFloat64(activeBackends.WithLabelValues("worker.example.com.")))
+
+	// Only the last run is returned
+	_, err = push.Report(false, "draining", 0)
+	assert.NoError(t, err)
+	results = g.runHealthChecksNow(healthcheckFilter{Address: "10.0.0.1"})
+	assert.Len(t, results, 1)
+	assert.False(t, results[0].Alive)
+	assert.Len(t, results[0].Checks[0].Results, 1)
+	assert.Equal(t, "protocol", results[0].Checks[0].Results[0].Reason)
+	assert.Equal(t, float64(1), testutil.ToFloat64(activeBackends.WithLabelValues("worker.example.com.")))
+
+	assert.Empty(t, g.runHealthChecksNow(healthcheckFilter{Location: "nowhere"}))
+}
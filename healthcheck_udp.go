@@ -0,0 +1,140 @@
+package gslb
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/creasty/defaults"
+)
+
+// UDPHealthCheck represents UDP-specific health check settings.
+type UDPHealthCheck struct {
+	Port           int    `yaml:"port" default:"53"`              // UDP port to send the payload to
+	Timeout        string `yaml:"timeout" default:"5s"`           // Maximum time to wait for a response
+	Send           string `yaml:"send" default:""`                // Payload to send, escape sequences allowed
+	SendHex        string `yaml:"send_hex" default:""`            // Payload to send, hex encoded
+	Expect         string `yaml:"expect" default:""`              // Regex the response must match
+	ExpectHex      string `yaml:"expect_hex" default:""`          // Hex encoded bytes the response must contain
+	ExpectResponse bool   `yaml:"expect_response" default:"true"` // If false, the absence of ICMP error until timeout means healthy
+}
+
+// SetDefault applies default values to UDPHealthCheck fields.
+func (h *UDPHealthCheck) SetDefault() {
+	defaults.Set(h)
+}
+
+// GetType returns the type of the health check as a string.
+func (h *UDPHealthCheck) GetType() string {
+	return fmt.Sprintf("udp/%d", h.Port)
+}
+
+// PerformCheck sends a datagram to the backend and validates the response.
+func (h *UDPHealthCheck) PerformCheck(backend *Backend, fqdn string, maxRetries int) bool {
+	typeStr := h.GetType()
+	address := backend.Address
+	start := time.Now()
+	result := false
+	defer func() {
+		ObserveHealthcheck(fqdn, typeStr, address, start, result)
+	}()
+
+	timeout, err := time.ParseDuration(h.Timeout)
+	if err != nil {
+		log.Errorf("[%s] invalid timeout format: %v", fqdn, err)
+		backend.ReportFailure(typeStr, "timeout", err)
+		return false
+	}
+
+	payload, err := decodePayload(h.Send, h.SendHex)
+	if err != nil {
+		log.Errorf("[%s] invalid UDP send payload: %v", fqdn, err)
+		backend.ReportFailure(typeStr, "other", err)
+		return false
+	}
+	matcher, err := newResponseMatcher(h.Expect, h.ExpectHex)
+	if err != nil {
+		log.Errorf("[%s] invalid UDP expect pattern: %v", fqdn, err)
+		backend.ReportFailure(typeStr, "other", err)
+		return false
+	}
+
+	addressPort := net.JoinHostPort(backend.Address, strconv.Itoa(h.Port))
+	for retry := 0; retry <= maxRetries; retry++ {
+		log.Debugf("[%s] Attempting UDP health check on %s", fqdn, addressPort)
+
+		err := h.exchange(addressPort, payload, matcher, timeout)
+		if err != nil {
+			log.Debugf("[%s] UDP health check failed (retries=%d/%d): %v", fqdn, retry, maxRetries, err)
+			if retry == maxRetries {
+				backend.ReportFailure(typeStr, failureReason(err), err)
+				return false
+			}
+			continue
+		}
+
+		log.Debugf("[%s] UDP health check successful for %s", fqdn, addressPort)
+		result = true
+		return true
+	}
+
+	backend.ReportFailure(typeStr, "other", nil)
+	return false
+}
+
+// exchange sends the payload and waits for a datagram matching the expected pattern.
+// The socket is connected so that ICMP port-unreachable is reported as ECONNREFUSED.
+func (h *UDPHealthCheck) exchange(addressPort string, payload []byte, matcher *responseMatcher, timeout time.Duration) error {
+	conn, err := net.DialTimeout("udp", addressPort, timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	if _, err := conn.Write(payload); err != nil {
+		return fmt.Errorf("failed to send payload: %w", err)
+	}
+
+	var lastResponse []byte
+	buf := make([]byte, 65535)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				if lastResponse != nil {
+					return fmt.Errorf("response %q does not match expected pattern", lastResponse)
+				}
+				if !h.ExpectResponse {
+					return nil
+				}
+			}
+			return err
+		}
+		if matcher == nil || matcher.Match(buf[:n]) {
+			return nil
+		}
+		lastResponse = append([]byte(nil), buf[:n]...)
+	}
+}
+
+// Equals compares two UDPHealthCheck objects for equality.
+func (h *UDPHealthCheck) Equals(other GenericHealthCheck) bool {
+	otherUDP, ok := other.(*UDPHealthCheck)
+	if !ok {
+		return false
+	}
+
+	return h.Port == otherUDP.Port &&
+		h.Timeout == otherUDP.Timeout &&
+		h.Send == otherUDP.Send &&
+		h.SendHex == otherUDP.SendHex &&
+		h.Expect == otherUDP.Expect &&
+		h.ExpectHex == otherUDP.ExpectHex &&
+		h.ExpectResponse == otherUDP.ExpectResponse
+}
@@ -0,0 +1,65 @@
+package gslb
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUDPHealthCheck(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen on UDP: %v", err)
+	}
+	defer conn.Close()
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+
+	// Echo server prefixing the response with "ACK "
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			conn.WriteTo(append([]byte("ACK "), buf[:n]...), addr)
+		}
+	}()
+
+	// A port with nothing listening, so the kernel answers with ICMP port-unreachable
+	closed, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen on UDP: %v", err)
+	}
+	closedPort := closed.LocalAddr().(*net.UDPAddr).Port
+	closed.Close()
+
+	backend := &Backend{Address: "127.0.0.1"}
+
+	tests := []struct {
+		name     string
+		hc       *UDPHealthCheck
+		expected bool
+	}{
+		{"Match", &UDPHealthCheck{Port: port, Timeout: "1s", Send: "ping", Expect: "^ACK ping$", ExpectResponse: true}, true},
+		{"AnyResponse", &UDPHealthCheck{Port: port, Timeout: "1s", SendHex: "0102", ExpectResponse: true}, true},
+		{"Mismatch", &UDPHealthCheck{Port: port, Timeout: "200ms", Send: "ping", Expect: "^PONG", ExpectResponse: true}, false},
+		{"PortUnreachable", &UDPHealthCheck{Port: closedPort, Timeout: "1s", Send: "ping", ExpectResponse: true}, false},
+		{"PortUnreachableNoResponseExpected", &UDPHealthCheck{Port: closedPort, Timeout: "500ms", Send: "ping", ExpectResponse: false}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.hc.PerformCheck(backend, "example.com", 0))
+		})
+	}
+}
+
+func TestUDPHealthCheck_Equals(t *testing.T) {
+	hc1 := &UDPHealthCheck{Port: 514, Timeout: "1s", Send: "ping"}
+	hc2 := &UDPHealthCheck{Port: 514, Timeout: "1s", Send: "ping"}
+	hc3 := &UDPHealthCheck{Port: 1812, Timeout: "1s", Send: "ping"}
+
+	assert.True(t, hc1.Equals(hc2))
+	assert.False(t, hc1.Equals(hc3))
+}
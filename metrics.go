@@ -131,6 +131,26 @@ var (
 		},
 		[]string{"name", "address", "type"},
 	)
+	backendCertificateExpiry = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gslb_backend_certificate_expiry_timestamp_seconds",
+			Help: "Expiry time of the certificate presented by a backend per healthcheck type (unix timestamp).",
+		},
+		[]string{"name", "address", "type"},
+	)
+	healthcheckQueueDepth = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "gslb_healthcheck_queue_depth",
+			Help: "Number of backend healthchecks waiting for a worker.",
+		},
+	)
+	healthcheckShared = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gslb_healthcheck_shared_total",
+			Help: "Total number of healthcheck results reused from a probe shared with another record.",
+		},
+		[]string{"type", "address"},
+	)
 )
 
 var metricsOnce sync.Once
@@ -153,6 +173,9 @@ func RegisterMetrics() {
 		prometheus.MustRegister(recordHealthStatus)
 		prometheus.MustRegister(backendHealthStatus)
 		prometheus.MustRegister(backendHealthcheckStatus)
+		prometheus.MustRegister(backendCertificateExpiry)
+		prometheus.MustRegister(healthcheckShared)
+		prometheus.MustRegister(healthcheckQueueDepth)
 	})
 }
 
@@ -164,6 +187,14 @@ func ObserveHealthcheckDuration(typ, address string, duration float64) {
 	healthcheckDuration.WithLabelValues(typ, address).Observe(duration)
 }
 
+func IncHealthcheckShared(typ, address string) {
+	healthcheckShared.WithLabelValues(typ, address).Inc()
+}
+
+func SetHealthcheckQueueDepth(value float64) {
+	healthcheckQueueDepth.Set(value)
+}
+
 func IncRecordResolutions(name, result string) {
 	recordResolutions.WithLabelValues(name, result).Inc()
 }
@@ -213,6 +244,10 @@ func SetBackendHealthcheckStatus(name, address, typeStr string, value float64) {
 	backendHealthcheckStatus.WithLabelValues(name, address, typeStr).Set(value)
 }
 
+func SetBackendCertificateExpiry(name, address, typeStr string, value float64) {
+	backendCertificateExpiry.WithLabelValues(name, address, typeStr).Set(value)
+}
+
 func ObserveHealthcheck(name, typeStr, address string, start time.Time, result bool) {
 	// Log the health check result
 	// log.Debugf("Record health check for metrics: type=%s, address=%s, result=%t", typeStr, address, result)
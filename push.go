@@ -0,0 +1,336 @@
+package gslb
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+var (
+	errPushRecordNotFound  = errors.New("record not found")
+	errPushBackendNotFound = errors.New("backend not found")
+	errPushNotConfigured   = errors.New("backend has no push healthcheck")
+)
+
+// pushHealthChecks returns the push health checks of a backend of a record.
+func (g *GSLB) pushHealthChecks(fqdn, address string) ([]*PushHealthCheck, error) {
+	g.Mutex.RLock()
+	record, _ := g.findRecord(dns.Fqdn(strings.ToLower(fqdn)))
+	g.Mutex.RUnlock()
+	if record == nil {
+		return nil, errPushRecordNotFound
+	}
+
+	record.mutex.RLock()
+	defer record.mutex.RUnlock()
+	for _, backend := range record.Backends {
+		if backend.GetAddress() != address {
+			continue
+		}
+		var checks []*PushHealthCheck
+		for _, hc := range backend.GetHealthChecks() {
+			if push, ok := hc.(*PushHealthCheck); ok {
+				checks = append(checks, push)
+			}
+		}
+		if len(checks) == 0 {
+			return nil, errPushNotConfigured
+		}
+		return checks, nil
+	}
+	return nil, errPushBackendNotFound
+}
+
+// reportBackendHealth applies a pushed health report to the push health checks of a backend.
+// The backend health is updated right away, and again when the report expires.
+func (g *GSLB) reportBackendHealth(fqdn, address string, healthy bool, message string, ttl time.Duration) (time.Time, error) {
+	checks, err := g.pushHealthChecks(fqdn, address)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var expires time.Time
+	for _, push := range checks {
+		if expires, err = push.Report(healthy, message, ttl); err != nil {
+			return time.Time{}, err
+		}
+		push.onExpiry(func() { g.refreshPushBackend(fqdn, address) })
+	}
+	savePushReport(fqdn, address, pushReport{healthy: healthy, message: message, expires: expires})
+	log.Debugf("[%s] health pushed for backend %s: healthy=%v expires=%s", dns.Fqdn(fqdn), address, healthy, expires.Format(time.RFC3339))
+	g.refreshPushBackend(fqdn, address)
+	return expires, nil
+}
+
+// pushReport is the last health report received for a backend. The reports
+// are kept across reloads, the new configuration starts from them instead of
+// waiting for the agents to report again.
+type pushReport struct {
+	healthy bool
+	message string
+	expires time.Time
+}
+
+var (
+	pushReportsMutex sync.Mutex
+	pushReports      = make(map[string]pushReport) // fqdn|address -> last report
+)
+
+func pushReportKey(fqdn, address string) string {
+	return dns.Fqdn(strings.ToLower(fqdn)) + "|" + address
+}
+
+func savePushReport(fqdn, address string, report pushReport) {
+	pushReportsMutex.Lock()
+	defer pushReportsMutex.Unlock()
+	pushReports[pushReportKey(fqdn, address)] = report
+}
+
+// restorePushReports applies the reports which are still valid to the push
+// health checks of the records, after a load or a reload. The expired reports
+// are dropped. The caller must hold g.Mutex.
+func (g *GSLB) restorePushReports() {
+	pushReportsMutex.Lock()
+	defer pushReportsMutex.Unlock()
+	now := time.Now()
+	for key, report := range pushReports {
+		if !report.expires.After(now) {
+			delete(pushReports, key)
+		}
+	}
+	if len(pushReports) == 0 {
+		return
+	}
+
+	for _, records := range g.Records {
+		for fqdn, record := range records {
+			record.mutex.RLock()
+			for _, backend := range record.Backends {
+				report, ok := pushReports[pushReportKey(fqdn, backend.GetAddress())]
+				if !ok {
+					continue
+				}
+				address := backend.GetAddress()
+				for _, hc := range backend.GetHealthChecks() {
+					if push, ok := hc.(*PushHealthCheck); ok && push.restore(report) {
+						log.Debugf("[%s] push report of backend %s restored, expires at %s", fqdn, address, report.expires.Format(time.RFC3339))
+						push.onExpiry(func() { g.refreshPushBackend(fqdn, address) })
+					}
+				}
+			}
+			record.mutex.RUnlock()
+		}
+	}
+}
+
+// refreshPushBackend runs the push health checks of a backend and updates the
+// record status, without waiting for the next run of the record health checks.
+func (g *GSLB) refreshPushBackend(fqdn, address string) {
+	g.Mutex.RLock()
+	record, _ := g.findRecord(dns.Fqdn(strings.ToLower(fqdn)))
+	g.Mutex.RUnlock()
+	if record == nil {
+		return
+	}
+
+	var backend *Backend
+	record.mutex.RLock()
+	settings := g.scrapeSettings(record)
+	for _, b := range record.Backends {
+		if b.GetAddress() == address {
+			backend, _ = b.(*Backend)
+		}
+	}
+	record.mutex.RUnlock()
+	if backend == nil {
+		return
+	}
+	settings.pushOnly = true
+	backend.runHealthChecks(settings)
+	record.mutex.RLock()
+	record.refreshHealthStatus()
+	record.mutex.RUnlock()
+}
+
+// pushDNSServer is the DNS UPDATE server listening on an address. On a CoreDNS
+// reload the new instance starts before the old one is shut down, so it takes
+// the running server over instead of listening on the address again.
+type pushDNSServer struct {
+	server    *dns.Server
+	keys      map[string]string
+	instances []*GSLB // Instances using the server, the last one started handles the updates
+}
+
+var (
+	pushDNSServersMutex sync.Mutex
+	pushDNSServers      = make(map[string]*pushDNSServer)
+)
+
+// handler returns the instance handling the updates received by the server.
+func (p *pushDNSServer) handler() *GSLB {
+	pushDNSServersMutex.Lock()
+	defer pushDNSServersMutex.Unlock()
+	return p.instances[len(p.instances)-1]
+}
+
+// ServePushDNS starts the DNS server accepting TSIG signed UPDATE messages
+// carrying health reports, or takes over the server already listening on the
+// address. The server is restarted when the TSIG keys changed.
+func (g *GSLB) ServePushDNS() error {
+	pushDNSServersMutex.Lock()
+	defer pushDNSServersMutex.Unlock()
+
+	p := pushDNSServers[g.PushDNSListenAddr]
+	if p != nil && maps.Equal(p.keys, g.PushTSIGKeys) {
+		p.instances = append(p.instances, g)
+		return nil
+	}
+	if p != nil {
+		log.Infof("Restarting push DNS server on %s, the TSIG keys changed", g.PushDNSListenAddr)
+		p.server.Shutdown()
+	} else {
+		p = &pushDNSServer{}
+	}
+
+	conn, err := net.ListenPacket("udp", g.PushDNSListenAddr)
+	if err != nil {
+		delete(pushDNSServers, g.PushDNSListenAddr)
+		return fmt.Errorf("push DNS server: %w", err)
+	}
+	started := make(chan struct{})
+	p.server = &dns.Server{
+		PacketConn:        conn,
+		Handler:           dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) { p.handler().handlePushUpdate(w, r) }),
+		TsigSecret:        g.PushTSIGKeys,
+		MsgAcceptFunc:     acceptPushUpdate,
+		NotifyStartedFunc: func() { close(started) },
+	}
+	p.keys = g.PushTSIGKeys
+	p.instances = append(p.instances, g)
+	pushDNSServers[g.PushDNSListenAddr] = p
+	go func(server *dns.Server) {
+		if err := server.ActivateAndServe(); err != nil {
+			log.Errorf("Push DNS server on %s stopped: %v", g.PushDNSListenAddr, err)
+		}
+	}(p.server)
+	<-started
+	return nil
+}
+
+// stopPushDNS releases the push DNS server used by the instance, it is shut
+// down once no instance uses it.
+func (g *GSLB) stopPushDNS() {
+	pushDNSServersMutex.Lock()
+	defer pushDNSServersMutex.Unlock()
+
+	p := pushDNSServers[g.PushDNSListenAddr]
+	if p == nil {
+		return
+	}
+	p.instances = slices.DeleteFunc(p.instances, func(other *GSLB) bool { return other == g })
+	if len(p.instances) == 0 {
+		p.server.Shutdown()
+		delete(pushDNSServers, g.PushDNSListenAddr)
+	}
+}
+
+// acceptPushUpdate accepts UPDATE messages only.
+func acceptPushUpdate(dh dns.Header) dns.MsgAcceptAction {
+	if dh.Bits&(1<<15) != 0 {
+		return dns.MsgIgnore
+	}
+	if opcode := int(dh.Bits>>11) & 0xF; opcode != dns.OpcodeUpdate {
+		return dns.MsgRejectNotImplemented
+	}
+	return dns.MsgAccept
+}
+
+// handlePushUpdate applies the health reports of a DNS UPDATE. Each report is a
+// TXT record added at the record name, holding the backend address, the status
+// (healthy or unhealthy) and an optional message. The TTL of the TXT record is
+// the validity of the report, 0 for the TTL of the push health check.
+//
+//	app.example.com. 30 IN TXT "10.0.0.1" "healthy" "queue empty"
+func (g *GSLB) handlePushUpdate(w dns.ResponseWriter, r *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	defer func() {
+		if t := r.IsTsig(); t != nil && len(g.PushTSIGKeys) > 0 && w.TsigStatus() == nil {
+			m.SetTsig(t.Hdr.Name, t.Algorithm, 300, time.Now().Unix())
+		}
+		w.WriteMsg(m)
+	}()
+
+	// Without keys the server does not verify signatures, refuse everything
+	if len(g.PushTSIGKeys) == 0 || r.IsTsig() == nil || w.TsigStatus() != nil {
+		log.Warningf("Push DNS update refused: missing or invalid TSIG")
+		m.Rcode = dns.RcodeNotAuth
+		return
+	}
+	if len(r.Question) != 1 || r.Question[0].Qtype != dns.TypeSOA {
+		m.Rcode = dns.RcodeFormatError
+		return
+	}
+	zone := dns.Fqdn(strings.ToLower(r.Question[0].Name))
+
+	// Validate all reports before applying any of them
+	type pushReport struct {
+		fqdn, address, message string
+		healthy                bool
+		ttl                    time.Duration
+	}
+	var reports []pushReport
+	for _, rr := range r.Ns {
+		txt, ok := rr.(*dns.TXT)
+		if !ok || rr.Header().Class != dns.ClassINET || len(txt.Txt) < 2 {
+			m.Rcode = dns.RcodeFormatError
+			return
+		}
+		report := pushReport{
+			fqdn:    strings.ToLower(txt.Hdr.Name),
+			address: txt.Txt[0],
+			message: strings.Join(txt.Txt[2:], " "),
+			ttl:     time.Duration(txt.Hdr.Ttl) * time.Second,
+		}
+		if !dns.IsSubDomain(zone, report.fqdn) {
+			m.Rcode = dns.RcodeNotZone
+			return
+		}
+		var err error
+		if report.healthy, err = parsePushStatus(txt.Txt[1]); err != nil {
+			m.Rcode = dns.RcodeFormatError
+			return
+		}
+		if _, err := g.pushHealthChecks(report.fqdn, report.address); err != nil {
+			log.Warningf("[%s] push DNS update refused for backend %s: %v", report.fqdn, report.address, err)
+			m.Rcode = dns.RcodeNameError
+			return
+		}
+		reports = append(reports, report)
+	}
+
+	for _, report := range reports {
+		if _, err := g.reportBackendHealth(report.fqdn, report.address, report.healthy, report.message, report.ttl); err != nil {
+			log.Warningf("[%s] push DNS update failed for backend %s: %v", report.fqdn, report.address, err)
+			m.Rcode = dns.RcodeServerFailure
+			return
+		}
+	}
+}
+
+// parsePushStatus converts a reported status to a health value.
+func parsePushStatus(status string) (bool, error) {
+	switch strings.ToLower(status) {
+	case statusHealthy:
+		return true, nil
+	case statusUnhealthy:
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid status '%s', expected %s or %s", status, statusHealthy, statusUnhealthy)
+}
@@ -0,0 +1,210 @@
+package gslb
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+// newPushTestGSLB returns a GSLB with one record whose backend has a push health check.
+func newPushTestGSLB() (*GSLB, *PushHealthCheck) {
+	push := &PushHealthCheck{TTL: "1m", Token: "agent-token"}
+	g := &GSLB{
+		Records: map[string]map[string]*Record{
+			"example.com.": {
+				"worker.example.com.": {
+					Fqdn: "worker.example.com.",
+					Backends: []BackendInterface{
+						&Backend{Address: "10.0.0.1", Enable: true, HealthChecks: []GenericHealthCheck{push}},
+						&Backend{Address: "10.0.0.2", Enable: true, HealthChecks: []GenericHealthCheck{&MockHealthCheck{}}},
+					},
+				},
+			},
+		},
+	}
+	return g, push
+}
+
+func TestGSLB_ReportBackendHealth(t *testing.T) {
+	g, push := newPushTestGSLB()
+	backend := &Backend{Address: "10.0.0.1"}
+
+	_, err := g.reportBackendHealth("Worker.example.com", "10.0.0.1", true, "ok", 0)
+	assert.NoError(t, err)
+	assert.True(t, push.PerformCheck(backend, "worker.example.com.", 0))
+
+	// The backend health is updated without waiting for the next scrape
+	worker := g.Records["example.com."]["worker.example.com."].Backends[0]
+	assert.True(t, worker.IsHealthy())
+	_, err = g.reportBackendHealth("worker.example.com.", "10.0.0.1", false, "draining", 0)
+	assert.NoError(t, err)
+	assert.False(t, worker.IsHealthy())
+
+	_, err = g.reportBackendHealth("unknown.example.com.", "10.0.0.1", true, "", 0)
+	assert.ErrorIs(t, err, errPushRecordNotFound)
+	_, err = g.reportBackendHealth("worker.example.com.", "10.0.0.9", true, "", 0)
+	assert.ErrorIs(t, err, errPushBackendNotFound)
+	_, err = g.reportBackendHealth("worker.example.com.", "10.0.0.2", true, "", 0)
+	assert.ErrorIs(t, err, errPushNotConfigured)
+}
+
+func TestGSLB_PushDNSUpdate(t *testing.T) {
+	g, push := newPushTestGSLB()
+	backend := &Backend{Address: "10.0.0.1"}
+	secret := "c2VjcmV0LXNlY3JldC1zZWNyZXQ="
+	g.PushTSIGKeys = map[string]string{"agent.": secret}
+
+	g.PushDNSListenAddr = freeUDPAddress(t)
+	assert.NoError(t, g.ServePushDNS())
+	defer g.stopPushDNS()
+
+	newUpdate := func(status string, ttl uint32) *dns.Msg {
+		m := new(dns.Msg)
+		m.SetUpdate("example.com.")
+		m.Insert([]dns.RR{&dns.TXT{
+			Hdr: dns.RR_Header{Name: "worker.example.com.", Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: ttl},
+			Txt: []string{"10.0.0.1", status, "pushed by agent"},
+		}})
+		return m
+	}
+	client := &dns.Client{TsigSecret: map[string]string{"agent.": secret}}
+	address := g.PushDNSListenAddr
+
+	// Unsigned updates are refused
+	resp, _, err := client.Exchange(newUpdate("healthy", 30), address)
+	assert.NoError(t, err)
+	assert.Equal(t, dns.RcodeNotAuth, resp.Rcode)
+	assert.False(t, push.PerformCheck(backend, "worker.example.com.", 0))
+
+	signed := newUpdate("healthy", 30)
+	signed.SetTsig("agent.", dns.HmacSHA256, 300, time.Now().Unix())
+	resp, _, err = client.Exchange(signed, address)
+	assert.NoError(t, err)
+	assert.Equal(t, dns.RcodeSuccess, resp.Rcode)
+	assert.True(t, push.PerformCheck(backend, "worker.example.com.", 0))
+	assert.Equal(t, "pushed by agent", backend.GetCheckDetails()["push"])
+
+	// Wrong key
+	badClient := &dns.Client{TsigSecret: map[string]string{"agent.": "b3RoZXItc2VjcmV0"}}
+	bad := newUpdate("unhealthy", 30)
+	bad.SetTsig("agent.", dns.HmacSHA256, 300, time.Now().Unix())
+	resp, _, _ = badClient.Exchange(bad, address)
+	if resp != nil {
+		assert.Equal(t, dns.RcodeNotAuth, resp.Rcode)
+	}
+	assert.True(t, push.PerformCheck(backend, "worker.example.com.", 0))
+
+	invalid := newUpdate("maybe", 30)
+	invalid.SetTsig("agent.", dns.HmacSHA256, 300, time.Now().Unix())
+	resp, _, err = client.Exchange(invalid, address)
+	assert.NoError(t, err)
+	assert.Equal(t, dns.RcodeFormatError, resp.Rcode)
+
+	unhealthy := newUpdate("unhealthy", 30)
+	unhealthy.SetTsig("agent.", dns.HmacSHA256, 300, time.Now().Unix())
+	resp, _, err = client.Exchange(unhealthy, address)
+	assert.NoError(t, err)
+	assert.Equal(t, dns.RcodeSuccess, resp.Rcode)
+	assert.False(t, push.PerformCheck(backend, "worker.example.com.", 0))
+}
+
+func TestGSLB_PushHealthExpiry(t *testing.T) {
+	g, _ := newPushTestGSLB()
+	worker := g.Records["example.com."]["worker.example.com."].Backends[0]
+
+	_, err := g.reportBackendHealth("worker.example.com.", "10.0.0.1", true, "", 50*time.Millisecond)
+	assert.NoError(t, err)
+	assert.True(t, worker.IsHealthy())
+
+	// The backend becomes unhealthy when the report expires, before the next scrape
+	assert.Eventually(t, func() bool { return !worker.IsHealthy() }, time.Second, 10*time.Millisecond)
+}
+
+// freeUDPAddress returns a local UDP address which is not in use.
+func freeUDPAddress(t *testing.T) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen on UDP: %v", err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().String()
+}
+
+func TestGSLB_ServePushDNS_Reload(t *testing.T) {
+	secret := "c2VjcmV0LXNlY3JldC1zZWNyZXQ="
+	address := freeUDPAddress(t)
+	newInstance := func() (*GSLB, *PushHealthCheck) {
+		g, push := newPushTestGSLB()
+		g.PushDNSListenAddr = address
+		g.PushTSIGKeys = map[string]string{"agent.": secret}
+		return g, push
+	}
+	send := func() int {
+		m := new(dns.Msg)
+		m.SetUpdate("example.com.")
+		m.Insert([]dns.RR{&dns.TXT{
+			Hdr: dns.RR_Header{Name: "worker.example.com.", Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 30},
+			Txt: []string{"10.0.0.1", "healthy"},
+		}})
+		m.SetTsig("agent.", dns.HmacSHA256, 300, time.Now().Unix())
+		client := &dns.Client{TsigSecret: map[string]string{"agent.": secret}, Timeout: time.Second}
+		resp, _, err := client.Exchange(m, address)
+		if err != nil {
+			return -1
+		}
+		return resp.Rcode
+	}
+	backend := &Backend{Address: "10.0.0.1"}
+
+	oldGSLB, oldPush := newInstance()
+	assert.NoError(t, oldGSLB.ServePushDNS())
+
+	// The new instance starts before the old one is shut down, it takes the server over
+	newGSLB, newPush := newInstance()
+	assert.NoError(t, newGSLB.ServePushDNS())
+	oldGSLB.stopPushDNS()
+
+	assert.Equal(t, dns.RcodeSuccess, send())
+	assert.True(t, newPush.PerformCheck(backend, "worker.example.com.", 0))
+	assert.False(t, oldPush.PerformCheck(backend, "worker.example.com.", 0))
+
+	// Changed keys restart the server on the same address
+	rotated, _ := newInstance()
+	rotated.PushTSIGKeys = map[string]string{"agent.": "b3RoZXItc2VjcmV0"}
+	assert.NoError(t, rotated.ServePushDNS())
+	newGSLB.stopPushDNS()
+	assert.NotEqual(t, dns.RcodeSuccess, send())
+
+	// The address is released once no instance uses the server
+	rotated.stopPushDNS()
+	conn, err := net.ListenPacket("udp", address)
+	assert.NoError(t, err)
+	if conn != nil {
+		conn.Close()
+	}
+}
+
+func TestGSLB_RestorePushReports(t *testing.T) {
+	previous, _ := newPushTestGSLB()
+	_, err := previous.reportBackendHealth("worker.example.com.", "10.0.0.1", true, "carried over", time.Minute)
+	assert.NoError(t, err)
+
+	// The new configuration of a reload starts from the last report
+	g, push := newPushTestGSLB()
+	g.restorePushReports()
+	backend := &Backend{Address: "10.0.0.1"}
+	assert.True(t, push.PerformCheck(backend, "worker.example.com.", 0))
+	assert.Equal(t, "carried over", backend.GetCheckDetails()["push"])
+
+	// Expired reports are dropped
+	savePushReport("worker.example.com.", "10.0.0.1", pushReport{healthy: true, expires: time.Now().Add(-time.Second)})
+	g, push = newPushTestGSLB()
+	g.restorePushReports()
+	assert.False(t, push.PerformCheck(backend, "worker.example.com.", 0))
+	pushReportsMutex.Lock()
+	assert.NotContains(t, pushReports, pushReportKey("worker.example.com.", "10.0.0.1"))
+	pushReportsMutex.Unlock()
+}
@@ -3,6 +3,7 @@ package gslb
 import (
 	"context"
 	"fmt"
+	"net"
 	"sync"
 	"time"
 
@@ -21,7 +22,9 @@ type Record struct {
 	ScrapeInterval string
 	ScrapeRetries  int
 	ScrapeTimeout  string
-	ticker         *time.Ticker
+	DependsOn      []Dependency       // Records which must have a healthy backend for the backends of this record to be healthy
+	idle           bool               // Set while the health checks are slowed down because the record is not resolved
+	hostnames      []*hostnameBackend // Backends configured with a hostname, expanded into Backends once resolved
 	mutex          sync.RWMutex
 	cancelFunc     context.CancelFunc
 }
@@ -36,6 +39,7 @@ func (r *Record) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		ScrapeRetries  int           `yaml:"scrape_retries" default:"1"`
 		ScrapeTimeout  string        `yaml:"scrape_timeout" default:"5s"`
 		Backends       []interface{} `yaml:"backends"`
+		DependsOn      []Dependency  `yaml:"depends_on"`
 	}
 	defaults.Set(&raw)
 
@@ -50,6 +54,7 @@ func (r *Record) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	r.ScrapeInterval = raw.ScrapeInterval
 	r.ScrapeRetries = raw.ScrapeRetries
 	r.ScrapeTimeout = raw.ScrapeTimeout
+	r.DependsOn = raw.DependsOn
 
 	for _, backendData := range raw.Backends {
 		var backend Backend
@@ -63,6 +68,13 @@ func (r *Record) UnmarshalYAML(unmarshal func(interface{}) error) error {
 			return fmt.Errorf("failed to decode backend: %w", err)
 		}
 
+		if net.ParseIP(backend.Address) == nil {
+			if !isHostname(backend.Address) {
+				return fmt.Errorf("invalid backend address %s, expected an IP address or a hostname", backend.Address)
+			}
+			r.hostnames = append(r.hostnames, &hostnameBackend{Hostname: backend.Address, definition: backendYaml})
+			continue
+		}
 		r.Backends = append(r.Backends, &backend)
 	}
 	// No direct call to SetBackendsTotal or SetRecordsTotal here; these are set globally after all records are loaded/updated.
@@ -97,7 +109,6 @@ func (r *Record) updateRecord(newRecord *Record) {
 	if r.ScrapeInterval != newRecord.ScrapeInterval {
 		log.Debugf("[%s] scrape interval changed from %s to %s", r.Fqdn, r.ScrapeInterval, newRecord.ScrapeInterval)
 		r.ScrapeInterval = newRecord.ScrapeInterval
-		r.ticker.Reset(r.GetScrapeInterval())
 	}
 
 	if r.ScrapeRetries != newRecord.ScrapeRetries {
@@ -110,6 +121,11 @@ func (r *Record) updateRecord(newRecord *Record) {
 		r.ScrapeTimeout = newRecord.ScrapeTimeout
 	}
 
+	if !dependenciesEqual(r.DependsOn, newRecord.DependsOn) {
+		log.Debugf("[%s] dependencies changed", r.Fqdn)
+		r.DependsOn = newRecord.DependsOn
+	}
+
 	// Update or add backends
 	for _, newBackend := range newRecord.Backends {
 		newBackend.SetFqdn(r.Fqdn)
@@ -124,19 +140,20 @@ func (r *Record) updateRecord(newRecord *Record) {
 			}
 		}
 
-		// add new backend and start healthcheck
+		// add new backend, the scheduler runs its health checks as it is due
 		if !found {
 			log.Debugf("[%s] new backend added %s", r.Fqdn, newBackend.GetAddress())
 			r.Backends = append(r.Backends, newBackend)
-			if newBackend.IsEnabled() {
-				go newBackend.runHealthChecks(r.ScrapeRetries, r.GetScrapeTimeout())
-			}
 		}
 	}
 
-	// Remove deleted backends
+	// Remove deleted backends, the ones resolved from a hostname are updated below
 	for i := 0; i < len(r.Backends); {
 		backend := r.Backends[i]
+		if isResolved(backend) {
+			i++
+			continue
+		}
 		found := false
 		for _, newBackend := range newRecord.Backends {
 			if backend.GetAddress() == newBackend.GetAddress() {
@@ -151,6 +168,8 @@ func (r *Record) updateRecord(newRecord *Record) {
 			i++
 		}
 	}
+
+	r.updateHostnames(newRecord.hostnames)
 }
 
 // GetScrapeInterval returns the health check interval for HTTPHealthCheck
@@ -163,78 +182,44 @@ func (r *Record) GetScrapeTimeout() time.Duration {
 	return parseDurationWithDefault(r.ScrapeTimeout, "5s")
 }
 
-func (r *Record) scrapeBackends(ctx context.Context, g *GSLB) {
-	// Initialize ticker if it does not exist
-	scrapeInterval := r.GetScrapeInterval()
-	if r.ticker == nil {
-		r.ticker = time.NewTicker(scrapeInterval)
-		defer r.ticker.Stop()
+// idleMultiplier returns the multiplier of the health check intervals, greater
+// than 1 when the record was not resolved for a while.
+func (r *Record) idleMultiplier(g *GSLB) int {
+	multiplier := 1
+	if value, exists := g.LastResolution.Load(r.Fqdn); exists {
+		if time.Since(value.(time.Time)) > g.GetResolutionIdleTimeout() && g.HealthcheckIdleMultiplier > 1 {
+			multiplier = g.HealthcheckIdleMultiplier
+		}
 	}
 
-	// for tracing only
-	for _, backend := range r.Backends {
-		backend.SetFqdn(r.Fqdn)
+	if idle := multiplier > 1; idle != r.idle {
+		if idle {
+			log.Debugf("[%s] Slow down scrape interval to %s", r.Fqdn, r.GetScrapeInterval()*time.Duration(multiplier))
+		} else {
+			log.Debugf("[%s] Resume normal scrape interval to %s", r.Fqdn, r.GetScrapeInterval())
+		}
+		r.idle = idle
 	}
+	return multiplier
+}
 
-	for {
-		select {
-		case <-r.ticker.C:
-			now := time.Now()
-
-			// Check if scraping should be slowed down
-			shouldSlowDown := false
-			value, exists := g.LastResolution.Load(r.Fqdn)
-			if exists {
-				lastResolution := value.(time.Time)
-				if now.Sub(lastResolution) > g.GetResolutionIdleTimeout() {
-					shouldSlowDown = true
-				}
-			}
-
-			// Adjust the scraping interval based on activity
-			newInterval := r.GetScrapeInterval()
-			if shouldSlowDown {
-				newInterval = r.GetScrapeInterval() * time.Duration(g.HealthcheckIdleMultiplier)
-			}
-
-			// If the interval changes, reset the ticker
-			if newInterval != scrapeInterval {
-				scrapeInterval = newInterval
-				r.ticker.Reset(scrapeInterval)
-				if shouldSlowDown {
-					log.Debugf("[%s] Slow down scrape interval to %s", r.Fqdn, scrapeInterval)
-				} else {
-					log.Debugf("[%s] Resume normal scrape interval to %s", r.Fqdn, scrapeInterval)
-				}
-			}
-
-			// Run health checks for backends
-			for _, backend := range r.Backends {
-				backend.Lock()
-				if !backend.IsEnabled() {
-					backend.Unlock()
-					continue
-				}
-				backend.Unlock()
-				backend.runHealthChecks(r.ScrapeRetries, r.GetScrapeTimeout())
-			}
-
-			// Update Prometheus gauge for active backends
-			healthyCount := 0
-			for _, backend := range r.Backends {
-				if backend.IsHealthy() {
-					healthyCount++
-				}
-			}
-			SetActiveBackends(r.Fqdn, float64(healthyCount))
+// scrapeSettings returns the health check settings of the record. The caller must hold the mutex.
+func (r *Record) scrapeSettings() scrapeSettings {
+	return scrapeSettings{retries: r.ScrapeRetries, timeout: r.GetScrapeTimeout(), interval: r.GetScrapeInterval()}
+}
 
-			// Update record health status
-			r.updateRecordHealthStatus()
-		case <-ctx.Done():
-			log.Debugf("[%s] stopping health checks", r.Fqdn)
-			return
+// applySourceBinding resolves the global source binding into the health checks
+// of the backends, those resolved from a hostname included. It is called on
+// records which are loaded and not used yet.
+func (r *Record) applySourceBinding(global SourceBinding) {
+	for _, backend := range r.Backends {
+		if b, ok := backend.(*Backend); ok {
+			b.applySourceBinding(global)
 		}
 	}
+	for _, h := range r.hostnames {
+		h.source = global
+	}
 }
 
 func parseDurationWithDefault(durationStr string, defaultStr string) time.Duration {
@@ -250,6 +235,19 @@ func (r *Record) UpdateRecord() {
 	r.updateRecordHealthStatus()
 }
 
+// refreshHealthStatus updates the active backends gauge and the health status
+// metrics of the record after a health check run. The caller must hold the mutex.
+func (r *Record) refreshHealthStatus() {
+	healthyCount := 0
+	for _, backend := range r.Backends {
+		if backend.IsHealthy() {
+			healthyCount++
+		}
+	}
+	SetActiveBackends(r.Fqdn, float64(healthyCount))
+	r.updateRecordHealthStatus()
+}
+
 func (r *Record) updateRecordHealthStatus() {
 	// Check if any backend is healthy
 	hasHealthyBackend := false
@@ -0,0 +1,233 @@
+package gslb
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// dependencyRefreshInterval is the delay between two evaluations of the record dependencies.
+const dependencyRefreshInterval = time.Second
+
+// Dependency references a record whose health a record or a backend depends on.
+// It is met when one of the backends of the record matching the selectors is healthy.
+type Dependency struct {
+	Record   string   `yaml:"record"`   // FQDN of the record
+	Location string   `yaml:"location"` // Only the backends of this location, if set
+	Address  string   `yaml:"address"`  // Only the backend with this address or hostname, if set
+	Tags     []string `yaml:"tags"`     // Only the backends with all these tags, if set
+}
+
+// UnmarshalYAML accepts the FQDN of the record alone or a mapping with selectors.
+func (d *Dependency) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var fqdn string
+	if err := unmarshal(&fqdn); err == nil {
+		*d = Dependency{Record: fqdn}
+	} else {
+		type plain Dependency
+		var raw plain
+		if err := unmarshal(&raw); err != nil {
+			return err
+		}
+		*d = Dependency(raw)
+	}
+	if d.Record == "" {
+		return fmt.Errorf("depends_on entry without record")
+	}
+	d.Record = dns.Fqdn(strings.ToLower(d.Record))
+	return nil
+}
+
+func (d Dependency) String() string {
+	var selectors []string
+	if d.Location != "" {
+		selectors = append(selectors, "location="+d.Location)
+	}
+	if d.Address != "" {
+		selectors = append(selectors, "address="+d.Address)
+	}
+	if len(d.Tags) > 0 {
+		selectors = append(selectors, "tags="+strings.Join(d.Tags, ","))
+	}
+	if len(selectors) == 0 {
+		return d.Record
+	}
+	return d.Record + "[" + strings.Join(selectors, " ") + "]"
+}
+
+// matches reports whether a backend of the record is selected by the dependency.
+func (d Dependency) matches(b dependencyBackend) bool {
+	if d.Location != "" && d.Location != b.location {
+		return false
+	}
+	if d.Address != "" && d.Address != b.address && d.Address != b.hostname {
+		return false
+	}
+	for _, tag := range d.Tags {
+		if !slices.Contains(b.tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+func dependenciesEqual(a, b []Dependency) bool {
+	return slices.EqualFunc(a, b, func(x, y Dependency) bool {
+		return x.Record == y.Record && x.Location == y.Location && x.Address == y.Address && slices.Equal(x.Tags, y.Tags)
+	})
+}
+
+// dependencyGraph is a snapshot of the records and the health of their
+// backends, the dependencies are evaluated on it without holding any lock.
+type dependencyGraph map[string]*dependencyRecord
+
+type dependencyRecord struct {
+	record    *Record
+	dependsOn []Dependency
+	backends  []dependencyBackend
+}
+
+type dependencyBackend struct {
+	backend   *Backend
+	address   string
+	hostname  string
+	location  string
+	tags      []string
+	healthy   bool // Health of the backend itself, dependencies excluded
+	dependsOn []Dependency
+}
+
+// dependencySnapshot returns the dependency graph of all records. The caller must hold g.Mutex.
+func (g *GSLB) dependencySnapshot() dependencyGraph {
+	graph := make(dependencyGraph)
+	for _, records := range g.Records {
+		for fqdn, record := range records {
+			record.mutex.RLock()
+			node := &dependencyRecord{record: record, dependsOn: record.DependsOn}
+			for _, backend := range record.Backends {
+				b, ok := backend.(*Backend)
+				if !ok {
+					continue
+				}
+				b.mutex.RLock()
+				node.backends = append(node.backends, dependencyBackend{
+					backend:   b,
+					address:   b.Address,
+					hostname:  b.Hostname,
+					location:  b.Location,
+					tags:      b.Tags,
+					healthy:   b.Alive && b.Enable,
+					dependsOn: b.DependsOn,
+				})
+				b.mutex.RUnlock()
+			}
+			record.mutex.RUnlock()
+			graph[strings.ToLower(fqdn)] = node
+		}
+	}
+	return graph
+}
+
+// firstUnmet returns the first dependency of the list which is not met, or nil.
+// The path holds the records being evaluated: a dependency on one of them is a
+// cycle, which is ignored so that the records do not keep each other down.
+func (graph dependencyGraph) firstUnmet(dependencies []Dependency, path []string) *Dependency {
+	for i := range dependencies {
+		if !graph.met(dependencies[i], path) {
+			return &dependencies[i]
+		}
+	}
+	return nil
+}
+
+// met reports whether a backend of the record of the dependency is healthy,
+// its own dependencies included.
+func (graph dependencyGraph) met(dependency Dependency, path []string) bool {
+	if slices.Contains(path, dependency.Record) {
+		return true
+	}
+	node, ok := graph[dependency.Record]
+	if !ok {
+		return false
+	}
+	path = append(slices.Clip(path), dependency.Record)
+	if graph.firstUnmet(node.dependsOn, path) != nil {
+		return false
+	}
+	for _, b := range node.backends {
+		if b.healthy && dependency.matches(b) && graph.firstUnmet(b.dependsOn, path) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// refreshDependencies evaluates the dependencies of the records and of their
+// backends from the last health state, and refreshes the health status of the
+// records whose backends changed.
+func (g *GSLB) refreshDependencies() {
+	g.Mutex.RLock()
+	graph := g.dependencySnapshot()
+	g.Mutex.RUnlock()
+	for fqdn, node := range graph {
+		path := []string{fqdn}
+		recordUnmet := graph.firstUnmet(node.dependsOn, path)
+		changed := false
+		for _, b := range node.backends {
+			unmet := recordUnmet
+			if unmet == nil {
+				unmet = graph.firstUnmet(b.dependsOn, path)
+			}
+			failure := ""
+			if unmet != nil {
+				failure = unmet.String()
+			}
+			if b.backend.setDependencyFailure(failure) {
+				changed = true
+			}
+		}
+		if changed {
+			node.record.mutex.RLock()
+			node.record.refreshHealthStatus()
+			node.record.mutex.RUnlock()
+		}
+	}
+}
+
+// runDependencyEvaluator evaluates the record dependencies periodically, until
+// the context is done.
+func (g *GSLB) runDependencyEvaluator(ctx context.Context) {
+	ticker := time.NewTicker(dependencyRefreshInterval)
+	defer ticker.Stop()
+	for {
+		g.refreshDependencies()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkDependencies logs the dependencies referencing a record which does not
+// exist. The caller must hold g.Mutex.
+func (g *GSLB) checkDependencies() {
+	graph := g.dependencySnapshot()
+	for fqdn, node := range graph {
+		dependencies := slices.Clone(node.dependsOn)
+		for _, b := range node.backends {
+			dependencies = append(dependencies, b.dependsOn...)
+		}
+		unknown := make(map[string]bool)
+		for _, dependency := range dependencies {
+			if _, ok := graph[dependency.Record]; !ok && !unknown[dependency.Record] {
+				unknown[dependency.Record] = true
+				log.Warningf("[%s] depends on unknown record %s, the dependency is never met", fqdn, dependency.Record)
+			}
+		}
+	}
+}
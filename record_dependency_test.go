@@ -0,0 +1,219 @@
+package gslb
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// newDependencyTestGSLB returns a GSLB with the records of a zone file, all backends alive.
+func newDependencyTestGSLB(t *testing.T, config string) *GSLB {
+	var raw struct {
+		Records map[string]*Record `yaml:"records"`
+	}
+	require.NoError(t, yaml.Unmarshal([]byte(config), &raw))
+	g := &GSLB{Records: map[string]map[string]*Record{"gslb.example.com.": {}}}
+	for fqdn, record := range raw.Records {
+		record.Fqdn = fqdn
+		for _, backend := range record.Backends {
+			backend.SetFqdn(fqdn)
+			backend.(*Backend).Alive = true
+		}
+		g.Records["gslb.example.com."][fqdn] = record
+	}
+	return g
+}
+
+func setAlive(g *GSLB, fqdn, address string, alive bool) {
+	for _, backend := range g.Records["gslb.example.com."][fqdn].Backends {
+		if backend.GetAddress() == address {
+			b := backend.(*Backend)
+			b.mutex.Lock()
+			b.Alive = alive
+			b.mutex.Unlock()
+		}
+	}
+}
+
+func isHealthy(g *GSLB, fqdn, address string) bool {
+	for _, backend := range g.Records["gslb.example.com."][fqdn].Backends {
+		if backend.GetAddress() == address {
+			return backend.IsHealthy()
+		}
+	}
+	return false
+}
+
+func TestDependency_UnmarshalYAML(t *testing.T) {
+	var dependencies []Dependency
+	err := yaml.Unmarshal([]byte(`
+- API.gslb.example.com
+- record: db.gslb.example.com.
+  location: dc1
+  tags: [primary]
+`), &dependencies)
+	require.NoError(t, err)
+	assert.Equal(t, []Dependency{
+		{Record: "api.gslb.example.com."},
+		{Record: "db.gslb.example.com.", Location: "dc1", Tags: []string{"primary"}},
+	}, dependencies)
+	assert.Equal(t, "db.gslb.example.com.[location=dc1 tags=primary]", dependencies[1].String())
+
+	err = yaml.Unmarshal([]byte(`[{location: dc1}]`), &dependencies)
+	assert.ErrorContains(t, err, "without record")
+}
+
+func TestRefreshDependencies_Record(t *testing.T) {
+	g := newDependencyTestGSLB(t, `
+records:
+  api.gslb.example.com.:
+    backends:
+      - address: 10.0.1.1
+      - address: 10.0.2.1
+  web.gslb.example.com.:
+    depends_on: [api.gslb.example.com.]
+    backends:
+      - address: 10.1.1.1
+      - address: 10.1.2.1
+`)
+
+	g.refreshDependencies()
+	assert.True(t, isHealthy(g, "web.gslb.example.com.", "10.1.1.1"))
+
+	// One healthy backend of the API record is enough
+	setAlive(g, "api.gslb.example.com.", "10.0.1.1", false)
+	g.refreshDependencies()
+	assert.True(t, isHealthy(g, "web.gslb.example.com.", "10.1.1.1"))
+
+	setAlive(g, "api.gslb.example.com.", "10.0.2.1", false)
+	g.refreshDependencies()
+	assert.False(t, isHealthy(g, "web.gslb.example.com.", "10.1.1.1"))
+	assert.False(t, isHealthy(g, "web.gslb.example.com.", "10.1.2.1"))
+	assert.Equal(t, "api.gslb.example.com.", g.Records["gslb.example.com."]["web.gslb.example.com."].Backends[0].(*Backend).GetDependencyFailure())
+
+	// The health state of the backend itself is kept
+	backend := g.Records["gslb.example.com."]["web.gslb.example.com."].Backends[0].(*Backend)
+	assert.True(t, backend.Alive)
+
+	setAlive(g, "api.gslb.example.com.", "10.0.2.1", true)
+	g.refreshDependencies()
+	assert.True(t, isHealthy(g, "web.gslb.example.com.", "10.1.1.1"))
+	assert.Empty(t, backend.GetDependencyFailure())
+}
+
+func TestRefreshDependencies_Backend(t *testing.T) {
+	g := newDependencyTestGSLB(t, `
+records:
+  api.gslb.example.com.:
+    backends:
+      - address: 10.0.1.1
+        location: dc1
+      - address: 10.0.2.1
+        location: dc2
+  web.gslb.example.com.:
+    mode: failover
+    backends:
+      - address: 10.1.1.1
+        priority: 1
+        depends_on:
+          - record: api.gslb.example.com.
+            location: dc1
+      - address: 10.1.2.1
+        priority: 2
+        depends_on:
+          - record: api.gslb.example.com.
+            location: dc2
+`)
+
+	setAlive(g, "api.gslb.example.com.", "10.0.1.1", false)
+	g.refreshDependencies()
+	assert.False(t, isHealthy(g, "web.gslb.example.com.", "10.1.1.1"))
+	assert.True(t, isHealthy(g, "web.gslb.example.com.", "10.1.2.1"))
+
+	// The failover mode moves to the backend whose dependency is met
+	ips, err := g.pickBackendWithFailover(g.Records["gslb.example.com."]["web.gslb.example.com."], dns.TypeA)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.1.2.1"}, ips)
+}
+
+func TestRefreshDependencies_Transitive(t *testing.T) {
+	g := newDependencyTestGSLB(t, `
+records:
+  db.gslb.example.com.:
+    backends:
+      - address: 10.0.0.1
+  api.gslb.example.com.:
+    depends_on: [db.gslb.example.com.]
+    backends:
+      - address: 10.0.1.1
+  web.gslb.example.com.:
+    depends_on: [api.gslb.example.com.]
+    backends:
+      - address: 10.1.1.1
+`)
+
+	setAlive(g, "db.gslb.example.com.", "10.0.0.1", false)
+	g.refreshDependencies()
+	assert.False(t, isHealthy(g, "api.gslb.example.com.", "10.0.1.1"))
+	assert.False(t, isHealthy(g, "web.gslb.example.com.", "10.1.1.1"))
+
+	setAlive(g, "db.gslb.example.com.", "10.0.0.1", true)
+	g.refreshDependencies()
+	assert.True(t, isHealthy(g, "web.gslb.example.com.", "10.1.1.1"))
+}
+
+func TestRefreshDependencies_CycleAndUnknown(t *testing.T) {
+	g := newDependencyTestGSLB(t, `
+records:
+  a.gslb.example.com.:
+    depends_on: [b.gslb.example.com.]
+    backends:
+      - address: 10.0.0.1
+  b.gslb.example.com.:
+    depends_on: [a.gslb.example.com.]
+    backends:
+      - address: 10.0.0.2
+  c.gslb.example.com.:
+    depends_on: [unknown.gslb.example.com.]
+    backends:
+      - address: 10.0.0.3
+`)
+
+	setAlive(g, "a.gslb.example.com.", "10.0.0.1", false)
+	g.refreshDependencies()
+	assert.False(t, isHealthy(g, "b.gslb.example.com.", "10.0.0.2"))
+
+	// The records of a cycle recover with their own health
+	setAlive(g, "a.gslb.example.com.", "10.0.0.1", true)
+	g.refreshDependencies()
+	assert.True(t, isHealthy(g, "a.gslb.example.com.", "10.0.0.1"))
+	assert.True(t, isHealthy(g, "b.gslb.example.com.", "10.0.0.2"))
+
+	assert.False(t, isHealthy(g, "c.gslb.example.com.", "10.0.0.3"))
+}
+
+func TestRecord_UpdateRecord_Dependencies(t *testing.T) {
+	g := newDependencyTestGSLB(t, `
+records:
+  api.gslb.example.com.:
+    backends:
+      - address: 10.0.1.1
+  web.gslb.example.com.:
+    depends_on: [api.gslb.example.com.]
+    backends:
+      - address: 10.1.1.1
+`)
+	setAlive(g, "api.gslb.example.com.", "10.0.1.1", false)
+	g.refreshDependencies()
+	assert.False(t, isHealthy(g, "web.gslb.example.com.", "10.1.1.1"))
+
+	// Removing the dependency on reload restores the backend
+	web := g.Records["gslb.example.com."]["web.gslb.example.com."]
+	web.updateRecord(&Record{Backends: []BackendInterface{&Backend{Address: "10.1.1.1", Enable: true}}})
+	assert.Empty(t, web.DependsOn)
+	g.refreshDependencies()
+	assert.True(t, isHealthy(g, "web.gslb.example.com.", "10.1.1.1"))
+}
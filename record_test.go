@@ -81,6 +81,7 @@ func TestRecord_ScrapeBackends_Slowdown(t *testing.T) {
 	g := &GSLB{
 		ResolutionIdleTimeout:     idleTimeout,
 		HealthcheckIdleMultiplier: multiplier,
+		MaxStaggerStart:           "0s",
 	}
 	rec := &Record{
 		Fqdn:           "test.example.com.",
@@ -93,7 +94,7 @@ func TestRecord_ScrapeBackends_Slowdown(t *testing.T) {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	go rec.scrapeBackends(ctx, g)
+	g.startScheduler(ctx).schedule(ctx, rec)
 
 	time.Sleep(500 * time.Millisecond)
 	cancel()
@@ -108,8 +109,9 @@ type callCounter struct {
 	calls int32 // use atomic for thread safety
 }
 
-func (b *callCounter) runHealthChecks(retries int, timeout time.Duration) {
+func (b *callCounter) runHealthChecks(settings scrapeSettings) []CheckHistory {
 	atomic.AddInt32(&b.calls, 1)
+	return nil
 }
 func (b *callCounter) GetFqdn() string                           { return "test.example.com." }
 func (b *callCounter) SetFqdn(fqdn string)                       {}
@@ -0,0 +1,321 @@
+package gslb
+
+import (
+	"container/heap"
+	"context"
+	"math/rand"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// healthcheckScheduler runs the health checks of all records from a single
+// timeline on a bounded pool of workers, limiting the number of concurrent
+// checks per backend address.
+type healthcheckScheduler struct {
+	gslb              *GSLB
+	workers           int
+	maxPerDestination int
+	maxJitter         time.Duration
+
+	mutex    sync.Mutex
+	cond     *sync.Cond
+	queue    []*healthcheckJob // Jobs waiting for a worker, in submission order
+	running  map[string]int    // Running jobs by backend address
+	timeline recordTimeline
+	wakeup   chan struct{}
+	stopped  bool
+}
+
+// scheduledRecord is a record waiting in the timeline for its next run.
+type scheduledRecord struct {
+	ctx    context.Context
+	record *Record
+	next   time.Time
+	index  int
+}
+
+// recordRun tracks the backends of a record checked during one run.
+type recordRun struct {
+	entry    *scheduledRecord
+	settings scrapeSettings
+	pending  int32
+}
+
+// healthcheckJob runs the health checks of one backend.
+type healthcheckJob struct {
+	run     *recordRun
+	backend BackendInterface
+	done    *sync.WaitGroup // Set for on-demand jobs, which are not part of a scheduled record run
+	results []CheckHistory  // Results of an on-demand job, set once done
+}
+
+// recordTimeline is a min-heap of records ordered by next run.
+type recordTimeline []*scheduledRecord
+
+func (t recordTimeline) Len() int           { return len(t) }
+func (t recordTimeline) Less(i, j int) bool { return t[i].next.Before(t[j].next) }
+func (t recordTimeline) Swap(i, j int) {
+	t[i], t[j] = t[j], t[i]
+	t[i].index, t[j].index = i, j
+}
+func (t *recordTimeline) Push(x interface{}) {
+	entry := x.(*scheduledRecord)
+	entry.index = len(*t)
+	*t = append(*t, entry)
+}
+func (t *recordTimeline) Pop() interface{} {
+	old := *t
+	entry := old[len(old)-1]
+	*t = old[:len(old)-1]
+	return entry
+}
+
+func newHealthcheckScheduler(g *GSLB) *healthcheckScheduler {
+	s := &healthcheckScheduler{
+		gslb:              g,
+		workers:           g.HealthcheckWorkers,
+		maxPerDestination: g.HealthcheckMaxPerDestination,
+		maxJitter:         g.GetMaxStaggerStart(),
+		running:           make(map[string]int),
+		wakeup:            make(chan struct{}, 1),
+	}
+	if s.workers <= 0 {
+		s.workers = 64
+	}
+	s.cond = sync.NewCond(&s.mutex)
+	return s
+}
+
+// start launches the workers and the timeline loop until the context is done.
+func (s *healthcheckScheduler) start(ctx context.Context) {
+	for i := 0; i < s.workers; i++ {
+		go s.worker()
+	}
+	go s.loop(ctx)
+}
+
+// schedule adds a record to the timeline, its first run is delayed by a random
+// jitter so that records loaded together do not probe at the same time.
+func (s *healthcheckScheduler) schedule(ctx context.Context, r *Record) {
+	next := time.Now()
+	jitter := s.maxJitter
+	if interval := r.GetScrapeInterval(); interval < jitter {
+		jitter = interval
+	}
+	if jitter > 0 {
+		next = next.Add(time.Duration(rand.Int63n(int64(jitter))))
+	}
+	s.push(&scheduledRecord{ctx: ctx, record: r, next: next})
+}
+
+func (s *healthcheckScheduler) push(entry *scheduledRecord) {
+	s.mutex.Lock()
+	heap.Push(&s.timeline, entry)
+	s.mutex.Unlock()
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// wake moves the next run of a record waiting in the timeline to now, so that
+// its new backends are checked without waiting for the record interval. Only
+// the backends which are due are checked. A record being checked already is
+// put back in the timeline at the first backend due, new backends included.
+func (s *healthcheckScheduler) wake(r *Record) {
+	s.mutex.Lock()
+	for _, entry := range s.timeline {
+		if entry.record == r {
+			entry.next = time.Now()
+			heap.Fix(&s.timeline, entry.index)
+			break
+		}
+	}
+	s.mutex.Unlock()
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// loop dispatches the records when their next run is due.
+func (s *healthcheckScheduler) loop(ctx context.Context) {
+	for {
+		now := time.Now()
+		wait := time.Hour
+		var due []*scheduledRecord
+		s.mutex.Lock()
+		for s.timeline.Len() > 0 && !s.timeline[0].next.After(now) {
+			due = append(due, heap.Pop(&s.timeline).(*scheduledRecord))
+		}
+		if s.timeline.Len() > 0 {
+			wait = s.timeline[0].next.Sub(now)
+		}
+		s.mutex.Unlock()
+
+		for _, entry := range due {
+			s.dispatch(entry)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			s.mutex.Lock()
+			s.stopped = true
+			s.cond.Broadcast()
+			s.mutex.Unlock()
+			return
+		case <-s.wakeup:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// dispatch queues a job for each enabled backend of the record with a health check due.
+func (s *healthcheckScheduler) dispatch(entry *scheduledRecord) {
+	r := entry.record
+	if entry.ctx.Err() != nil {
+		log.Debugf("[%s] stopping health checks", r.Fqdn)
+		return
+	}
+
+	now := time.Now()
+	run := &recordRun{entry: entry}
+	r.mutex.RLock()
+	run.settings = s.gslb.scrapeSettings(r)
+	run.settings.idleFactor = r.idleMultiplier(s.gslb)
+	var backends []BackendInterface
+	for _, backend := range r.Backends {
+		backend.SetFqdn(r.Fqdn)
+		backend.Lock()
+		enabled := backend.IsEnabled()
+		backend.Unlock()
+		if b, ok := backend.(*Backend); ok && !b.isDue(now) {
+			continue
+		}
+		if enabled {
+			backends = append(backends, backend)
+		}
+	}
+	r.mutex.RUnlock()
+	entry.next = now.Add(run.settings.interval * time.Duration(run.settings.idleFactor))
+
+	if len(backends) == 0 {
+		s.finish(entry)
+		return
+	}
+	run.pending = int32(len(backends))
+
+	s.mutex.Lock()
+	for _, backend := range backends {
+		s.queue = append(s.queue, &healthcheckJob{run: run, backend: backend})
+	}
+	SetHealthcheckQueueDepth(float64(len(s.queue)))
+	s.cond.Broadcast()
+	s.mutex.Unlock()
+}
+
+// runNow queues on-demand jobs ahead of the scheduled ones, they run on the
+// same workers and within the same limits per backend address. The returned
+// WaitGroup is done once all the jobs ran and their results are set.
+func (s *healthcheckScheduler) runNow(jobs []*healthcheckJob) *sync.WaitGroup {
+	done := &sync.WaitGroup{}
+	done.Add(len(jobs))
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.stopped {
+		// The workers are gone, the jobs are left without results
+		done.Add(-len(jobs))
+		return done
+	}
+	for _, job := range jobs {
+		job.done = done
+	}
+	s.queue = slices.Concat(jobs, s.queue)
+	SetHealthcheckQueueDepth(float64(len(s.queue)))
+	s.cond.Broadcast()
+	return done
+}
+
+// nextJob removes and returns the first queued job whose backend address is
+// below the concurrency limit, or nil. The caller must hold the mutex.
+func (s *healthcheckScheduler) nextJob() *healthcheckJob {
+	for i, job := range s.queue {
+		if s.maxPerDestination > 0 && s.running[job.backend.GetAddress()] >= s.maxPerDestination {
+			continue
+		}
+		s.queue = append(s.queue[:i], s.queue[i+1:]...)
+		return job
+	}
+	return nil
+}
+
+func (s *healthcheckScheduler) worker() {
+	for {
+		s.mutex.Lock()
+		job := s.nextJob()
+		for job == nil {
+			if s.stopped {
+				s.mutex.Unlock()
+				return
+			}
+			s.cond.Wait()
+			job = s.nextJob()
+		}
+		address := job.backend.GetAddress()
+		s.running[address]++
+		SetHealthcheckQueueDepth(float64(len(s.queue)))
+		s.mutex.Unlock()
+
+		results := job.backend.runHealthChecks(job.run.settings)
+
+		s.mutex.Lock()
+		if s.running[address]--; s.running[address] <= 0 {
+			delete(s.running, address)
+		}
+		s.cond.Broadcast()
+		s.mutex.Unlock()
+
+		if job.done != nil {
+			job.results = results
+			job.done.Done()
+			continue
+		}
+		if atomic.AddInt32(&job.run.pending, -1) == 0 {
+			s.finish(job.run.entry)
+		}
+	}
+}
+
+// finish updates the record status once all its backends were checked and
+// puts the record back in the timeline, at the next health check due.
+func (s *healthcheckScheduler) finish(entry *scheduledRecord) {
+	r := entry.record
+	r.mutex.RLock()
+	r.refreshHealthStatus()
+	r.mutex.RUnlock()
+
+	if entry.ctx.Err() != nil {
+		log.Debugf("[%s] stopping health checks", r.Fqdn)
+		return
+	}
+
+	// Backends with their own schedule may be due before the record interval
+	r.mutex.RLock()
+	for _, backend := range r.Backends {
+		if b, ok := backend.(*Backend); ok && b.IsEnabled() {
+			if next := b.getNextRun(); next.Before(entry.next) {
+				entry.next = next
+			}
+		}
+	}
+	r.mutex.RUnlock()
+	if now := time.Now(); entry.next.Before(now) {
+		entry.next = now
+	}
+	s.push(entry)
+}
@@ -0,0 +1,274 @@
+package gslb
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// concurrency records the current and peak number of concurrent runs.
+type concurrency struct {
+	active int32
+	peak   int32
+}
+
+func (c *concurrency) enter() {
+	current := atomic.AddInt32(&c.active, 1)
+	for {
+		peak := atomic.LoadInt32(&c.peak)
+		if current <= peak || atomic.CompareAndSwapInt32(&c.peak, peak, current) {
+			return
+		}
+	}
+}
+
+func (c *concurrency) leave() {
+	atomic.AddInt32(&c.active, -1)
+}
+
+// blockingBackend blocks its health check runs until released.
+type blockingBackend struct {
+	callCounter
+	address  string
+	release  chan struct{}
+	counters []*concurrency
+}
+
+func (b *blockingBackend) GetAddress() string { return b.address }
+
+func (b *blockingBackend) runHealthChecks(settings scrapeSettings) []CheckHistory {
+	for _, c := range b.counters {
+		c.enter()
+	}
+	<-b.release
+	for _, c := range b.counters {
+		c.leave()
+	}
+	atomic.AddInt32(&b.calls, 1)
+	return nil
+}
+
+func TestHealthcheckScheduler_Limits(t *testing.T) {
+	RegisterMetrics()
+	g := &GSLB{HealthcheckWorkers: 4, HealthcheckMaxPerDestination: 2, MaxStaggerStart: "0s"}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	scheduler := g.startScheduler(ctx)
+
+	release := make(chan struct{})
+	all, sameAddress := &concurrency{}, &concurrency{}
+	var backends []*blockingBackend
+	for i := 0; i < 13; i++ {
+		// The last three records share the same address
+		backend := &blockingBackend{address: fmt.Sprintf("10.0.0.%d", i), release: release, counters: []*concurrency{all}}
+		if i >= 10 {
+			backend.address = "10.0.1.1"
+			backend.counters = append(backend.counters, sameAddress)
+		}
+		backends = append(backends, backend)
+		record := &Record{Fqdn: fmt.Sprintf("app%d.example.com.", i), ScrapeInterval: "1h"}
+		record.Backends = []BackendInterface{backend}
+		scheduler.schedule(ctx, record)
+	}
+
+	// Workers are all busy, the remaining jobs wait in the queue
+	assert.Eventually(t, func() bool {
+		scheduler.mutex.Lock()
+		defer scheduler.mutex.Unlock()
+		return atomic.LoadInt32(&all.active) == 4 && len(scheduler.queue) == 9
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, float64(9), testutil.ToFloat64(healthcheckQueueDepth))
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		total := int32(0)
+		for _, backend := range backends {
+			total += atomic.LoadInt32(&backend.calls)
+		}
+		return total == 13
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&all.peak))
+	assert.LessOrEqual(t, atomic.LoadInt32(&sameAddress.peak), int32(2))
+	assert.Equal(t, float64(0), testutil.ToFloat64(healthcheckQueueDepth))
+}
+
+func TestHealthcheckScheduler_RunNow(t *testing.T) {
+	RegisterMetrics()
+	g := &GSLB{HealthcheckWorkers: 4, HealthcheckMaxPerDestination: 1, MaxStaggerStart: "0s"}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	scheduler := g.startScheduler(ctx)
+
+	// On-demand jobs keep the limit per backend address
+	release := make(chan struct{})
+	sameAddress := &concurrency{}
+	var jobs []*healthcheckJob
+	for i := 0; i < 3; i++ {
+		backend := &blockingBackend{address: "10.0.1.1", release: release, counters: []*concurrency{sameAddress}}
+		jobs = append(jobs, &healthcheckJob{run: &recordRun{settings: scrapeSettings{force: true}}, backend: backend})
+	}
+	done := scheduler.runNow(jobs)
+
+	assert.Eventually(t, func() bool {
+		scheduler.mutex.Lock()
+		defer scheduler.mutex.Unlock()
+		return atomic.LoadInt32(&sameAddress.active) == 1 && len(scheduler.queue) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+	close(release)
+	done.Wait()
+	for _, job := range jobs {
+		assert.Equal(t, int32(1), atomic.LoadInt32(&job.backend.(*blockingBackend).calls))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&sameAddress.peak))
+
+	// After shutdown, the jobs are not run and the caller is not blocked
+	cancel()
+	assert.Eventually(t, func() bool {
+		scheduler.mutex.Lock()
+		defer scheduler.mutex.Unlock()
+		return scheduler.stopped
+	}, 2*time.Second, 10*time.Millisecond)
+	scheduler.runNow([]*healthcheckJob{{backend: &callCounter{}}}).Wait()
+}
+
+func TestHealthcheckScheduler_Jitter(t *testing.T) {
+	g := &GSLB{MaxStaggerStart: "1h"}
+	scheduler := newHealthcheckScheduler(g)
+	start := time.Now()
+	for i := 0; i < 20; i++ {
+		scheduler.schedule(context.Background(), &Record{ScrapeInterval: "200ms"})
+	}
+
+	// The jitter is bounded by the scrape interval
+	distinct := make(map[time.Time]bool)
+	for _, entry := range scheduler.timeline {
+		assert.False(t, entry.next.Before(start))
+		assert.True(t, entry.next.Before(time.Now().Add(200*time.Millisecond)))
+		distinct[entry.next] = true
+	}
+	assert.Greater(t, len(distinct), 1)
+}
+
+func TestHealthcheckScheduler_StopsRemovedRecords(t *testing.T) {
+	g := &GSLB{MaxStaggerStart: "0s"}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	record := &Record{Fqdn: "app.example.com.", ScrapeInterval: "50ms"}
+	backend := &callCounter{}
+	record.Backends = []BackendInterface{backend}
+	recordCtx, stop := context.WithCancel(ctx)
+	g.startScheduler(ctx).schedule(recordCtx, record)
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&backend.calls) >= 2 }, 2*time.Second, 10*time.Millisecond)
+	stop()
+	time.Sleep(100 * time.Millisecond)
+	calls := atomic.LoadInt32(&backend.calls)
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, calls, atomic.LoadInt32(&backend.calls))
+}
+
+// hangingHealthCheck blocks its probes until released, ignoring the scrape timeout.
+type hangingHealthCheck struct {
+	release chan struct{}
+	running *concurrency
+}
+
+func (h *hangingHealthCheck) PerformCheck(backend *Backend, fqdn string, maxRetries int) bool {
+	h.running.enter()
+	defer h.running.leave()
+	<-h.release
+	return true
+}
+func (h *hangingHealthCheck) GetType() string                      { return "hanging" }
+func (h *hangingHealthCheck) Equals(other GenericHealthCheck) bool { return false }
+
+func TestHealthcheckScheduler_HangingChecks(t *testing.T) {
+	g := &GSLB{HealthcheckWorkers: 2, MaxStaggerStart: "0s"}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	scheduler := g.startScheduler(ctx)
+
+	release := make(chan struct{})
+	running := &concurrency{}
+	var backends []*Backend
+	for i := 0; i < 6; i++ {
+		backend := &Backend{
+			Address:      fmt.Sprintf("10.0.2.%d", i),
+			Enable:       true,
+			HealthChecks: []GenericHealthCheck{&hangingHealthCheck{release: release, running: running}},
+		}
+		backends = append(backends, backend)
+		record := &Record{Fqdn: fmt.Sprintf("hang%d.example.com.", i), ScrapeInterval: "20ms", ScrapeTimeout: "10ms"}
+		record.Backends = []BackendInterface{backend}
+		scheduler.schedule(ctx, record)
+	}
+
+	// The probes still running after their timeout keep holding their worker
+	assert.Eventually(t, func() bool {
+		for _, backend := range backends {
+			if results := backend.GetCheckHistory()[0].Results; len(results) > 0 && results[0].Reason == "timeout" {
+				return true
+			}
+		}
+		return false
+	}, 2*time.Second, 10*time.Millisecond)
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&running.peak))
+
+	close(release)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&running.active) == 0 }, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestHealthcheckScheduler_WakeNewBackends(t *testing.T) {
+	g := &GSLB{MaxStaggerStart: "0s"}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	scheduler := g.startScheduler(ctx)
+
+	newBackend := func(address string) *Backend {
+		return &Backend{Address: address, Enable: true, HealthChecks: []GenericHealthCheck{&MockHealthCheckAPI{}}}
+	}
+	runs := func(backend *Backend) int { return len(backend.GetCheckHistory()[0].Results) }
+
+	existing := newBackend("10.0.3.1")
+	record := &Record{Fqdn: "wake.example.com.", ScrapeInterval: "1h", Backends: []BackendInterface{existing}}
+	scheduler.schedule(ctx, record)
+	assert.Eventually(t, func() bool { return runs(existing) == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	// A backend added by a reload is checked without waiting for the record interval
+	added := newBackend("10.0.3.2")
+	record.updateRecord(&Record{ScrapeInterval: "1h", Backends: []BackendInterface{newBackend("10.0.3.1"), added}})
+	scheduler.wake(record)
+	assert.Eventually(t, func() bool { return runs(added) == 1 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, runs(existing))
+}
+
+func TestHealthcheckScheduler_ConcurrentReload(t *testing.T) {
+	RegisterMetrics()
+	g := &GSLB{MaxStaggerStart: "0s"}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	scheduler := g.startScheduler(ctx)
+
+	newBackend := func(address string) *Backend {
+		return &Backend{Address: address, Enable: true, HealthChecks: []GenericHealthCheck{&MockHealthCheckAPI{}}}
+	}
+	record := &Record{Fqdn: "reload.example.com.", ScrapeInterval: "1ms", Backends: []BackendInterface{newBackend("10.0.4.1")}}
+	scheduler.schedule(ctx, record)
+
+	// The record status is refreshed while reloads change its backends
+	for i := 0; i < 200; i++ {
+		backends := []BackendInterface{newBackend("10.0.4.1")}
+		if i%2 == 0 {
+			backends = append(backends, newBackend("10.0.4.2"))
+		}
+		record.updateRecord(&Record{ScrapeInterval: "1ms", Backends: backends})
+		time.Sleep(time.Millisecond / 2)
+	}
+}
@@ -0,0 +1,109 @@
+package gslb
+
+import (
+	"fmt"
+	"time"
+)
+
+// scrapeOverrides are the scrape settings of a backend or a health check
+// replacing the ones of the record, empty values are inherited.
+type scrapeOverrides struct {
+	Interval string
+	Timeout  string
+	Retries  *int
+}
+
+// validate checks the format of the overridden settings.
+func (o scrapeOverrides) validate() error {
+	if o.Interval != "" {
+		if d, err := time.ParseDuration(o.Interval); err != nil || d <= 0 {
+			return fmt.Errorf("invalid scrape_interval '%s', expected a positive duration", o.Interval)
+		}
+	}
+	if o.Timeout != "" {
+		if d, err := time.ParseDuration(o.Timeout); err != nil || d <= 0 {
+			return fmt.Errorf("invalid scrape_timeout '%s', expected a positive duration", o.Timeout)
+		}
+	}
+	if o.Retries != nil && *o.Retries < 0 {
+		return fmt.Errorf("invalid scrape_retries %d, expected a positive number", *o.Retries)
+	}
+	return nil
+}
+
+// equals compares two sets of overrides.
+func (o scrapeOverrides) equals(other scrapeOverrides) bool {
+	if (o.Retries == nil) != (other.Retries == nil) || (o.Retries != nil && *o.Retries != *other.Retries) {
+		return false
+	}
+	return o.Interval == other.Interval && o.Timeout == other.Timeout
+}
+
+// apply replaces the given settings by the overridden ones.
+func (o scrapeOverrides) apply(retries *int, timeout, interval *time.Duration) {
+	if o.Retries != nil {
+		*retries = *o.Retries
+	}
+	if d, err := time.ParseDuration(o.Timeout); err == nil && d > 0 {
+		*timeout = d
+	}
+	if d, err := time.ParseDuration(o.Interval); err == nil && d > 0 {
+		*interval = d
+	}
+}
+
+// scrapeSettings are the settings of a health check run of a backend. They
+// come from the record and are overridden per backend and per health check.
+type scrapeSettings struct {
+	retries      int
+	timeout      time.Duration // Maximum duration of each health check, retries included
+	interval     time.Duration
+	idleFactor   int           // Multiplier of the intervals while the record is not resolved
+	backoffMax   time.Duration // Maximum interval of a failing health check, 0 disables the backoff
+	fastInterval time.Duration // Interval after a state change of the backend, 0 disables fast probing
+	fastWindow   time.Duration // Duration of the fast probing after a state change
+	force        bool          // Run all health checks, even those not due yet
+	pushOnly     bool          // Run the push health checks only, whatever their schedule
+}
+
+// checkSettings are the effective settings of one health check.
+type checkSettings struct {
+	retries  int
+	timeout  time.Duration
+	interval time.Duration
+}
+
+// forCheck returns the settings of a health check given the overrides of its backend and its own.
+func (s scrapeSettings) forCheck(backend, check scrapeOverrides) checkSettings {
+	c := checkSettings{retries: s.retries, timeout: s.timeout, interval: s.interval}
+	backend.apply(&c.retries, &c.timeout, &c.interval)
+	check.apply(&c.retries, &c.timeout, &c.interval)
+	if s.idleFactor > 1 {
+		c.interval *= time.Duration(s.idleFactor)
+	}
+	return c
+}
+
+// nextInterval returns the delay until the next run of a health check after
+// consecutive failures. Failing checks back off exponentially up to backoffMax,
+// unless the backend is in its fast probing window.
+func (s scrapeSettings) nextInterval(interval time.Duration, failures int, fast bool) time.Duration {
+	if fast {
+		return min(interval, s.fastInterval)
+	}
+	if failures > 1 && s.backoffMax > interval {
+		for n := 1; n < failures && interval < s.backoffMax; n++ {
+			interval *= 2
+		}
+		return min(interval, s.backoffMax)
+	}
+	return interval
+}
+
+// checkState is the scheduling state of one health check of a backend.
+type checkState struct {
+	next     time.Time // Next run, zero when never run
+	alive    bool
+	warning  bool
+	failures int // Consecutive failures
+}
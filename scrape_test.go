@@ -0,0 +1,210 @@
+package gslb
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+// countingHealthCheck counts its runs and returns the configured health.
+type countingHealthCheck struct {
+	name  string
+	runs  int32
+	alive atomic.Bool
+}
+
+func (c *countingHealthCheck) PerformCheck(backend *Backend, fqdn string, maxRetries int) bool {
+	atomic.AddInt32(&c.runs, 1)
+	return c.alive.Load()
+}
+func (c *countingHealthCheck) GetType() string { return c.name }
+func (c *countingHealthCheck) Equals(other GenericHealthCheck) bool {
+	return c == other
+}
+
+func newCountingHealthCheck(name string, alive bool) *countingHealthCheck {
+	c := &countingHealthCheck{name: name}
+	c.alive.Store(alive)
+	return c
+}
+
+func intPtr(v int) *int { return &v }
+
+func TestScrapeSettings_ForCheck(t *testing.T) {
+	settings := scrapeSettings{retries: 1, timeout: 5 * time.Second, interval: 10 * time.Second}
+
+	c := settings.forCheck(scrapeOverrides{}, scrapeOverrides{})
+	assert.Equal(t, checkSettings{retries: 1, timeout: 5 * time.Second, interval: 10 * time.Second}, c)
+
+	backend := scrapeOverrides{Interval: "5s", Retries: intPtr(0)}
+	c = settings.forCheck(backend, scrapeOverrides{})
+	assert.Equal(t, checkSettings{retries: 0, timeout: 5 * time.Second, interval: 5 * time.Second}, c)
+
+	check := scrapeOverrides{Interval: "1m", Timeout: "20s", Retries: intPtr(3)}
+	c = settings.forCheck(backend, check)
+	assert.Equal(t, checkSettings{retries: 3, timeout: 20 * time.Second, interval: time.Minute}, c)
+
+	// The idle slowdown applies to the overridden intervals as well
+	settings.idleFactor = 10
+	assert.Equal(t, 50*time.Second, settings.forCheck(backend, scrapeOverrides{}).interval)
+}
+
+func TestScrapeSettings_NextInterval(t *testing.T) {
+	settings := scrapeSettings{}
+	assert.Equal(t, 10*time.Second, settings.nextInterval(10*time.Second, 5, false))
+
+	settings.backoffMax = 2 * time.Minute
+	assert.Equal(t, 10*time.Second, settings.nextInterval(10*time.Second, 0, false))
+	assert.Equal(t, 10*time.Second, settings.nextInterval(10*time.Second, 1, false))
+	assert.Equal(t, 20*time.Second, settings.nextInterval(10*time.Second, 2, false))
+	assert.Equal(t, 80*time.Second, settings.nextInterval(10*time.Second, 4, false))
+	assert.Equal(t, 2*time.Minute, settings.nextInterval(10*time.Second, 5, false))
+	assert.Equal(t, 2*time.Minute, settings.nextInterval(10*time.Second, 1000, false))
+
+	settings.fastInterval = time.Second
+	assert.Equal(t, time.Second, settings.nextInterval(10*time.Second, 5, true))
+	assert.Equal(t, 500*time.Millisecond, settings.nextInterval(500*time.Millisecond, 0, true))
+}
+
+func TestScrapeOverrides_Validate(t *testing.T) {
+	assert.NoError(t, scrapeOverrides{}.validate())
+	assert.NoError(t, scrapeOverrides{Interval: "5s", Timeout: "1s", Retries: intPtr(0)}.validate())
+	assert.Error(t, scrapeOverrides{Interval: "often"}.validate())
+	assert.Error(t, scrapeOverrides{Timeout: "0s"}.validate())
+	assert.Error(t, scrapeOverrides{Retries: intPtr(-1)}.validate())
+
+	assert.True(t, scrapeOverrides{Retries: intPtr(2)}.equals(scrapeOverrides{Retries: intPtr(2)}))
+	assert.False(t, scrapeOverrides{Retries: intPtr(2)}.equals(scrapeOverrides{}))
+	assert.False(t, scrapeOverrides{Interval: "5s"}.equals(scrapeOverrides{Interval: "6s"}))
+}
+
+func TestBackend_UnmarshalScrapeOverrides(t *testing.T) {
+	data := `
+address: 10.0.0.1
+scrape_interval: 5s
+scrape_retries: 0
+healthchecks:
+  - type: tcp
+    params:
+      port: 80
+  - type: http
+    scrape_interval: 1m
+    scrape_timeout: 20s
+    scrape_retries: 3
+    params:
+      port: 443
+`
+	var backend Backend
+	assert.NoError(t, yaml.Unmarshal([]byte(data), &backend))
+	assert.Equal(t, "5s", backend.ScrapeInterval)
+	assert.Equal(t, 0, *backend.ScrapeRetries)
+	assert.Len(t, backend.checkOverrides, 2)
+	assert.Equal(t, scrapeOverrides{}, backend.checkOverrides[0])
+	assert.Equal(t, scrapeOverrides{Interval: "1m", Timeout: "20s", Retries: intPtr(3)}, backend.checkOverrides[1])
+
+	invalid := "address: 10.0.0.1\nhealthchecks:\n  - type: tcp\n    scrape_interval: never\n    params:\n      port: 80\n"
+	assert.ErrorContains(t, yaml.Unmarshal([]byte(invalid), &Backend{}), "invalid scrape_interval")
+	assert.ErrorContains(t, yaml.Unmarshal([]byte("address: 10.0.0.1\nscrape_timeout: -1s\n"), &Backend{}), "invalid scrape_timeout")
+}
+
+func TestBackend_RunHealthChecks_PerCheckInterval(t *testing.T) {
+	fast, slow := newCountingHealthCheck("fast", true), newCountingHealthCheck("slow", true)
+	backend := &Backend{
+		Address:        "10.0.0.1",
+		HealthChecks:   []GenericHealthCheck{fast, slow},
+		checkOverrides: []scrapeOverrides{{}, {Interval: "1h"}},
+	}
+	settings := scrapeSettings{timeout: time.Second, interval: 10 * time.Millisecond}
+
+	backend.runHealthChecks(settings)
+	assert.True(t, backend.Alive)
+	assert.WithinDuration(t, time.Now().Add(10*time.Millisecond), backend.getNextRun(), 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	// The slow check is not due, its last result is kept
+	slow.alive.Store(false)
+	backend.runHealthChecks(settings)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fast.runs))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&slow.runs))
+	assert.True(t, backend.Alive)
+
+	// A forced run runs every check
+	settings.force = true
+	backend.runHealthChecks(settings)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&slow.runs))
+	assert.False(t, backend.Alive)
+}
+
+func TestBackend_RunHealthChecks_BackoffAndFastProbing(t *testing.T) {
+	check := newCountingHealthCheck("check", true)
+	backend := &Backend{Address: "10.0.0.1", HealthChecks: []GenericHealthCheck{check}}
+	settings := scrapeSettings{timeout: time.Second, interval: 10 * time.Second, backoffMax: time.Minute, fastInterval: time.Second, fastWindow: time.Minute}
+	nextDelay := func() time.Duration {
+		return time.Until(backend.getNextRun()).Round(time.Second)
+	}
+
+	backend.runHealthChecks(settings)
+	assert.True(t, backend.Alive)
+	assert.Equal(t, 10*time.Second, nextDelay())
+
+	// The state change starts the fast probing window
+	check.alive.Store(false)
+	settings.force = true
+	backend.runHealthChecks(settings)
+	assert.False(t, backend.Alive)
+	assert.Equal(t, time.Second, nextDelay())
+	backend.runHealthChecks(settings)
+	assert.Equal(t, time.Second, nextDelay())
+
+	// Once the window is over, the failing check backs off
+	backend.mutex.Lock()
+	backend.fastUntil = time.Time{}
+	backend.mutex.Unlock()
+	backend.runHealthChecks(settings)
+	assert.Equal(t, 3, backend.checkStates[0].failures)
+	assert.Equal(t, 40*time.Second, nextDelay())
+	backend.runHealthChecks(settings)
+	assert.Equal(t, time.Minute, nextDelay())
+
+	// The first success resets the backoff and starts a new fast window
+	check.alive.Store(true)
+	backend.runHealthChecks(settings)
+	assert.True(t, backend.Alive)
+	assert.Equal(t, time.Second, nextDelay())
+}
+
+func TestHealthcheckScheduler_BackendInterval(t *testing.T) {
+	g := &GSLB{MaxStaggerStart: "0s"}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fast, slow := newCountingHealthCheck("fast", true), newCountingHealthCheck("slow", true)
+	record := &Record{Fqdn: "app.example.com.", ScrapeInterval: "1h", ScrapeTimeout: "1s"}
+	record.Backends = []BackendInterface{
+		&Backend{Address: "10.0.0.1", Enable: true, ScrapeInterval: "20ms", HealthChecks: []GenericHealthCheck{fast}},
+		&Backend{Address: "10.0.0.2", Enable: true, HealthChecks: []GenericHealthCheck{slow}},
+	}
+	g.startScheduler(ctx).schedule(ctx, record)
+
+	// The backend with a shorter interval is checked more often than the record interval
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&fast.runs) >= 3 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&slow.runs))
+}
+
+func TestGSLB_ProcessHealthchecksKeepsProfileOverrides(t *testing.T) {
+	g := &GSLB{HealthcheckProfiles: map[string]*HealthCheck{
+		"deep": {Type: "http", Params: map[string]interface{}{"port": 443}, ScrapeInterval: "1m", ScrapeRetries: intPtr(2)},
+	}}
+	result, err := g.processHealthchecks([]interface{}{"deep"})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"type":            "http",
+		"params":          map[string]interface{}{"port": 443},
+		"scrape_interval": "1m",
+		"scrape_retries":  2,
+	}}, result)
+}
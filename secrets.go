@@ -0,0 +1,154 @@
+package gslb
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	clog "github.com/coredns/coredns/plugin/pkg/log"
+)
+
+// secretRef matches the references to secrets in healthcheck params,
+// e.g. ${env:DB_PASSWORD} or ${file:/run/secrets/api_token}.
+var secretRef = regexp.MustCompile(`\$\{(env|file):([^}]+)\}`)
+
+// secretRedacted replaces the resolved secrets in logs and API responses.
+const secretRedacted = "******"
+
+// minSecretLength is the length below which resolved values are not redacted,
+// they would mask unrelated text.
+const minSecretLength = 4
+
+// secretValues holds the resolved secrets, to redact them.
+var secretValues = struct {
+	sync.RWMutex
+	values map[string]struct{}
+}{values: make(map[string]struct{})}
+
+// resolveSecrets returns a copy of healthcheck params with the secret references
+// of all strings, in nested maps and lists too, replaced by their values.
+func resolveSecrets(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return resolveSecretString(v)
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			r, err := resolveSecrets(item)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			resolved[key] = r
+		}
+		return resolved, nil
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, item := range v {
+			r, err := resolveSecrets(item)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = r
+		}
+		return resolved, nil
+	default:
+		return value, nil
+	}
+}
+
+// resolveSecretString replaces the secret references of a string by their values.
+func resolveSecretString(s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var resolveErr error
+	resolved := secretRef.ReplaceAllStringFunc(s, func(ref string) string {
+		match := secretRef.FindStringSubmatch(ref)
+		var secret string
+		switch match[1] {
+		case "env":
+			value, found := os.LookupEnv(match[2])
+			if !found {
+				resolveErr = fmt.Errorf("environment variable %s referenced by a secret is not set", match[2])
+				return ""
+			}
+			secret = value
+		case "file":
+			data, err := os.ReadFile(match[2])
+			if err != nil {
+				resolveErr = fmt.Errorf("failed to read secret file: %w", err)
+				return ""
+			}
+			secret = strings.TrimRight(string(data), "\r\n")
+		}
+		registerSecret(secret)
+		return secret
+	})
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+	return resolved, nil
+}
+
+// registerSecret remembers a resolved secret so that it is redacted.
+func registerSecret(secret string) {
+	if len(secret) < minSecretLength {
+		return
+	}
+	secretValues.Lock()
+	defer secretValues.Unlock()
+	secretValues.values[secret] = struct{}{}
+}
+
+// redactSecrets replaces the resolved secrets found in s.
+func redactSecrets(s string) string {
+	secretValues.RLock()
+	defer secretValues.RUnlock()
+	for secret := range secretValues.values {
+		s = strings.ReplaceAll(s, secret, secretRedacted)
+	}
+	return s
+}
+
+// redactingLogger is the plugin logger, redacting the resolved secrets of the messages.
+type redactingLogger struct {
+	clog.P
+}
+
+func (l redactingLogger) Debug(v ...interface{}) {
+	if clog.D.Value() {
+		l.P.Debug(redactSecrets(fmt.Sprint(v...)))
+	}
+}
+
+func (l redactingLogger) Debugf(format string, v ...interface{}) {
+	if clog.D.Value() {
+		l.P.Debug(redactSecrets(fmt.Sprintf(format, v...)))
+	}
+}
+
+func (l redactingLogger) Info(v ...interface{}) {
+	l.P.Info(redactSecrets(fmt.Sprint(v...)))
+}
+
+func (l redactingLogger) Infof(format string, v ...interface{}) {
+	l.P.Info(redactSecrets(fmt.Sprintf(format, v...)))
+}
+
+func (l redactingLogger) Warning(v ...interface{}) {
+	l.P.Warning(redactSecrets(fmt.Sprint(v...)))
+}
+
+func (l redactingLogger) Warningf(format string, v ...interface{}) {
+	l.P.Warning(redactSecrets(fmt.Sprintf(format, v...)))
+}
+
+func (l redactingLogger) Error(v ...interface{}) {
+	l.P.Error(redactSecrets(fmt.Sprint(v...)))
+}
+
+func (l redactingLogger) Errorf(format string, v ...interface{}) {
+	l.P.Error(redactSecrets(fmt.Sprintf(format, v...)))
+}
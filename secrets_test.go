@@ -0,0 +1,121 @@
+package gslb
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	t.Setenv("GSLB_TEST_DB_PASSWORD", "db-secret")
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("file-token\n"), 0600))
+
+	params := map[string]interface{}{
+		"password": "${env:GSLB_TEST_DB_PASSWORD}",
+		"headers":  map[string]interface{}{"Authorization": "Bearer ${file:" + tokenFile + "}"},
+		"args":     []interface{}{"--user", "${env:GSLB_TEST_DB_PASSWORD}"},
+		"command":  "echo ${HOME:-/root}", // Shell syntax is left untouched
+		"port":     3306,
+	}
+	resolved, err := resolveSecrets(params)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"password": "db-secret",
+		"headers":  map[string]interface{}{"Authorization": "Bearer file-token"},
+		"args":     []interface{}{"--user", "db-secret"},
+		"command":  "echo ${HOME:-/root}",
+		"port":     3306,
+	}, resolved)
+	// The params keep the references
+	assert.Equal(t, "${env:GSLB_TEST_DB_PASSWORD}", params["password"])
+
+	_, err = resolveSecrets(map[string]interface{}{"password": "${env:GSLB_TEST_UNDEFINED}"})
+	assert.ErrorContains(t, err, "GSLB_TEST_UNDEFINED")
+	_, err = resolveSecrets(map[string]interface{}{"password": "${file:/nonexistent/secret}"})
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+func TestRedactSecrets(t *testing.T) {
+	t.Setenv("GSLB_TEST_API_TOKEN", "s3cr3t-token")
+	t.Setenv("GSLB_TEST_SHORT", "ab")
+	_, err := resolveSecrets(map[string]interface{}{"token": "${env:GSLB_TEST_API_TOKEN}", "short": "${env:GSLB_TEST_SHORT}"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "401 for token ******", redactSecrets("401 for token s3cr3t-token"))
+	// Short values are not redacted
+	assert.Equal(t, "about", redactSecrets("about"))
+}
+
+func TestSecretsInHealthChecks(t *testing.T) {
+	t.Setenv("GSLB_TEST_MYSQL_PASSWORD", "mysql-secret")
+	hc := &HealthCheck{Type: "mysql", Params: map[string]interface{}{
+		"user":     "gslb",
+		"password": "${env:GSLB_TEST_MYSQL_PASSWORD}",
+	}}
+	specific, err := hc.ToSpecificHealthCheck()
+	require.NoError(t, err)
+	assert.Equal(t, "mysql-secret", specific.(*MySQLHealthCheck).Password)
+
+	// Errors reported to the API are redacted
+	backend := &Backend{Address: "127.0.0.1", HealthChecks: []GenericHealthCheck{specific}}
+	backend.ReportFailure("mysql/3306", "other", errors.New("login failed with mysql-secret"))
+	assert.Equal(t, "login failed with ******", backend.failureError)
+	backend.SetCheckDetail("mysql/3306", "password=mysql-secret")
+	assert.Equal(t, "password=******", backend.GetCheckDetails()["mysql/3306"])
+
+	hc.Params["password"] = "${env:GSLB_TEST_UNDEFINED}"
+	_, err = hc.ToSpecificHealthCheck()
+	assert.Error(t, err)
+}
+
+func TestSecretsReload(t *testing.T) {
+	passwordFile := filepath.Join(t.TempDir(), "mysql-password")
+	load := func(password string) *Backend {
+		require.NoError(t, os.WriteFile(passwordFile, []byte(password), 0600))
+		var backend Backend
+		require.NoError(t, yaml.Unmarshal([]byte(`
+address: 10.0.0.1
+healthchecks:
+  - type: mysql
+    params:
+      user: gslb
+      password: "${file:`+passwordFile+`}"
+`), &backend))
+		return &backend
+	}
+
+	// A rotated password changes the health check on reload
+	backend := load("old-secret")
+	backend.updateBackend(load("new-secret"))
+	assert.Equal(t, "new-secret", backend.HealthChecks[0].(*MySQLHealthCheck).Password)
+}
+
+func TestBulkSetBackendEnableKeepsSecretReferences(t *testing.T) {
+	t.Setenv("GSLB_TEST_HTTP_TOKEN", "http-secret")
+	zoneFile := filepath.Join(t.TempDir(), "zone.yml")
+	require.NoError(t, os.WriteFile(zoneFile, []byte(`records:
+  app.example.com.:
+    mode: failover
+    backends:
+      - address: 10.0.0.1
+        location: eu
+        healthchecks:
+          - type: http
+            params:
+              headers:
+                Authorization: "Bearer ${env:GSLB_TEST_HTTP_TOKEN}"
+`), 0644))
+
+	_, err := bulkSetBackendEnable(zoneFile, "eu", "", nil, false)
+	require.NoError(t, err)
+	data, err := os.ReadFile(zoneFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Bearer ${env:GSLB_TEST_HTTP_TOKEN}")
+	assert.NotContains(t, string(data), "http-secret")
+}
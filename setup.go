@@ -2,7 +2,9 @@ package gslb
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -12,6 +14,7 @@ import (
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
+	"github.com/miekg/dns"
 	"github.com/oschwald/geoip2-golang"
 	"gopkg.in/fsnotify.v1"
 	"gopkg.in/yaml.v3"
@@ -31,20 +34,23 @@ func setup(c *caddy.Controller) error {
 	config := dnsserver.GetConfig(c)
 
 	g := &GSLB{
-		Zones:                     make(map[string]string),
-		Records:                   make(map[string]map[string]*Record),
-		LocationMap:               make(map[string]string),
-		MaxStaggerStart:           "60s",
-		BatchSizeStart:            100,
-		ResolutionIdleTimeout:     "3600s",
-		UseEDNSCSubnet:            false,
-		HealthcheckIdleMultiplier: 10,
-		APIEnable:                 true,
-		APIListenAddr:             "0.0.0.0",
-		APIListenPort:             "8080",
+		Zones:                        make(map[string]string),
+		Records:                      make(map[string]map[string]*Record),
+		LocationMap:                  make(map[string]string),
+		MaxStaggerStart:              "60s",
+		BatchSizeStart:               100,
+		ResolutionIdleTimeout:        "3600s",
+		UseEDNSCSubnet:               false,
+		HealthcheckIdleMultiplier:    10,
+		HealthcheckWorkers:           64,
+		HealthcheckMaxPerDestination: 8,
+		APIEnable:                    true,
+		APIListenAddr:                "0.0.0.0",
+		APIListenPort:                "8080",
 	}
 
 	zoneFiles := make(map[string]string)
+	var source SourceBinding
 
 	for c.Next() {
 		if c.Val() == "gslb" {
@@ -91,6 +97,7 @@ func setup(c *caddy.Controller) error {
 						return fmt.Errorf("invalid value for batch_size_start: %v", c.Val())
 					}
 					g.BatchSizeStart = size
+					log.Warning("batch_size_start is deprecated and ignored, health checks are started with a random jitter up to max_stagger_start")
 				case "resolution_idle_timeout":
 					if !c.NextArg() {
 						return c.ArgErr()
@@ -147,6 +154,91 @@ func setup(c *caddy.Controller) error {
 						return fmt.Errorf("invalid value for healthcheck_idle_multiplier: %v", c.Val())
 					}
 					g.HealthcheckIdleMultiplier = mult
+				case "healthcheck_workers":
+					if !c.NextArg() {
+						return c.ArgErr()
+					}
+					workers, err := strconv.Atoi(c.Val())
+					if err != nil || workers < 1 {
+						return fmt.Errorf("invalid value for healthcheck_workers: %v", c.Val())
+					}
+					g.HealthcheckWorkers = workers
+				case "healthcheck_max_per_destination":
+					if !c.NextArg() {
+						return c.ArgErr()
+					}
+					limit, err := strconv.Atoi(c.Val())
+					if err != nil || limit < 0 {
+						return fmt.Errorf("invalid value for healthcheck_max_per_destination: %v", c.Val())
+					}
+					g.HealthcheckMaxPerDestination = limit
+				case "healthcheck_backoff_max":
+					if !c.NextArg() {
+						return c.ArgErr()
+					}
+					if d, err := time.ParseDuration(c.Val()); err != nil || d < 0 {
+						return fmt.Errorf("invalid value for healthcheck_backoff_max, expected duration format: %v", c.Val())
+					}
+					g.HealthcheckBackoffMax = c.Val()
+				case "healthcheck_fast_interval":
+					if !c.NextArg() {
+						return c.ArgErr()
+					}
+					if d, err := time.ParseDuration(c.Val()); err != nil || d < 0 {
+						return fmt.Errorf("invalid value for healthcheck_fast_interval, expected duration format: %v", c.Val())
+					}
+					g.HealthcheckFastInterval = c.Val()
+				case "healthcheck_fast_window":
+					if !c.NextArg() {
+						return c.ArgErr()
+					}
+					if d, err := time.ParseDuration(c.Val()); err != nil || d <= 0 {
+						return fmt.Errorf("invalid value for healthcheck_fast_window, expected duration format: %v", c.Val())
+					}
+					g.HealthcheckFastWindow = c.Val()
+				case "healthcheck_source_address":
+					if !c.NextArg() {
+						return c.ArgErr()
+					}
+					if net.ParseIP(c.Val()) == nil {
+						return fmt.Errorf("invalid value for healthcheck_source_address, expected an IP address: %v", c.Val())
+					}
+					source.SourceAddress = c.Val()
+				case "healthcheck_interface":
+					if !c.NextArg() {
+						return c.ArgErr()
+					}
+					source.Interface = c.Val()
+				case "healthcheck_so_mark":
+					if !c.NextArg() {
+						return c.ArgErr()
+					}
+					mark, err := strconv.Atoi(c.Val())
+					if err != nil || mark < 0 {
+						return fmt.Errorf("invalid value for healthcheck_so_mark: %v", c.Val())
+					}
+					source.Mark = mark
+				case "hostname_resolver":
+					if !c.NextArg() {
+						return c.ArgErr()
+					}
+					g.HostnameResolver = c.Val()
+				case "hostname_refresh_min":
+					if !c.NextArg() {
+						return c.ArgErr()
+					}
+					if d, err := time.ParseDuration(c.Val()); err != nil || d <= 0 {
+						return fmt.Errorf("invalid value for hostname_refresh_min, expected duration format: %v", c.Val())
+					}
+					g.HostnameRefreshMin = c.Val()
+				case "hostname_refresh_max":
+					if !c.NextArg() {
+						return c.ArgErr()
+					}
+					if d, err := time.ParseDuration(c.Val()); err != nil || d <= 0 {
+						return fmt.Errorf("invalid value for hostname_refresh_max, expected duration format: %v", c.Val())
+					}
+					g.HostnameRefreshMax = c.Val()
 				case "api_enable":
 					if !c.NextArg() {
 						return c.ArgErr()
@@ -203,6 +295,39 @@ func setup(c *caddy.Controller) error {
 						return fmt.Errorf("failed to parse global healthcheck_profiles: %w", err)
 					}
 					GlobalHealthcheckProfiles = tmp.HealthcheckProfiles
+				case "push_dns_listen":
+					if !c.NextArg() {
+						return c.ArgErr()
+					}
+					g.PushDNSListenAddr = c.Val()
+				case "push_tsig_key":
+					args := c.RemainingArgs()
+					if len(args) != 2 {
+						return c.ArgErr()
+					}
+					if _, err := base64.StdEncoding.DecodeString(args[1]); err != nil {
+						return fmt.Errorf("invalid secret for push_tsig_key %s, expected base64: %v", args[0], err)
+					}
+					if g.PushTSIGKeys == nil {
+						g.PushTSIGKeys = make(map[string]string)
+					}
+					g.PushTSIGKeys[dns.Fqdn(strings.ToLower(args[0]))] = args[1]
+				case "state_file":
+					if !c.NextArg() {
+						return c.ArgErr()
+					}
+					g.StateFile = c.Val()
+					if !filepath.IsAbs(g.StateFile) && config.Root != "" {
+						g.StateFile = filepath.Join(config.Root, g.StateFile)
+					}
+				case "state_save_interval":
+					if !c.NextArg() {
+						return c.ArgErr()
+					}
+					if d, err := time.ParseDuration(c.Val()); err != nil || d <= 0 {
+						return fmt.Errorf("invalid value for state_save_interval, expected duration format: %v", c.Val())
+					}
+					g.StateSaveInterval = c.Val()
 				case "disable_txt":
 					if c.NextArg() {
 						return c.ArgErr()
@@ -221,17 +346,52 @@ func setup(c *caddy.Controller) error {
 			if g.APIEnable {
 				go g.ServeAPI()
 			}
+			if g.PushDNSListenAddr != "" {
+				if len(g.PushTSIGKeys) == 0 {
+					return c.Errf("push_dns_listen requires at least one push_tsig_key")
+				}
+			}
 		}
 	}
 
+	if source.Interface != "" || source.Mark != 0 {
+		if _, err := source.control(); err != nil {
+			return c.Errf("invalid healthcheck source binding: %v", err)
+		}
+	}
+	g.HealthcheckSource = source
+	if g.GetHostnameRefreshMin() > g.GetHostnameRefreshMax() {
+		return c.Errf("hostname_refresh_min must not be greater than hostname_refresh_max")
+	}
+
 	// Add the Plugin to CoreDNS, so Servers can use it in their plugin chain.
 	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
 		g.Next = next
 		return g
 	})
 
-	// Initialize and load all records
-	g.initializeRecordsFromFiles(context.Background(), zoneFiles)
+	// Initialize and load all records, health checks stop when this instance is shut down
+	ctx, cancel := context.WithCancel(context.Background())
+	g.initializeRecordsFromFiles(ctx, zoneFiles)
+
+	if g.StateFile != "" {
+		go g.runStateSaver(ctx)
+	}
+	if g.PushDNSListenAddr != "" {
+		c.OnStartup(g.ServePushDNS)
+	}
+	c.OnShutdown(func() error {
+		cancel()
+		if g.PushDNSListenAddr != "" {
+			g.stopPushDNS()
+		}
+		if g.StateFile != "" {
+			if err := g.saveState(); err != nil {
+				log.Errorf("Failed to save state file: %v", err)
+			}
+		}
+		return nil
+	})
 
 	// All OK, return a nil error.
 	return nil
@@ -302,7 +462,7 @@ func reloadConfig(g *GSLB, filePath string, zone string) error {
 	defer g.Mutex.Unlock()
 
 	// Read YAML configuration
-	newGSLB := &GSLB{}
+	newGSLB := &GSLB{HealthcheckSource: g.HealthcheckSource}
 	if err := loadConfigFile(newGSLB, filePath, zone); err != nil {
 		IncConfigReloads("failure")
 		return err
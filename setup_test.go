@@ -78,6 +78,88 @@ func TestSetupGSLB(t *testing.T) {
 				api_basic_user testuser
 				api_basic_pass testpass
 				healthcheck_idle_multiplier 7
+				healthcheck_workers 32
+				healthcheck_max_per_destination 4
+				healthcheck_backoff_max 5m
+				healthcheck_fast_interval 1s
+				healthcheck_fast_window 20s
+			}`,
+			expectError: false,
+		},
+		// Test with the DNS UPDATE server for push healthchecks
+		{
+			name: "Push DNS update server",
+			config: `gslb {
+				zone app-x.gslb.example.com ./tests/db.app-x.gslb.example.com.yml
+				push_dns_listen 127.0.0.1:0
+				push_tsig_key agent. c2VjcmV0LXNlY3JldC1zZWNyZXQ=
+			}`,
+			expectError: false,
+		},
+		// Test with the healthcheck backoff and fast probing
+		{
+			name: "Healthcheck backoff and fast probing",
+			config: `gslb {
+				zone app-x.gslb.example.com ./tests/db.app-x.gslb.example.com.yml
+				healthcheck_backoff_max 5m
+				healthcheck_fast_interval 1s
+				healthcheck_fast_window 20s
+			}`,
+			expectError: false,
+		},
+		// Test with a global source binding of the probes
+		{
+			name: "Healthcheck source binding",
+			config: `gslb {
+				zone app-x.gslb.example.com ./tests/db.app-x.gslb.example.com.yml
+				healthcheck_source_address 127.0.0.1
+				healthcheck_so_mark 42
+			}`,
+			expectError: false,
+		},
+		{
+			name: "Invalid healthcheck source address",
+			config: `gslb {
+				zone app-x.gslb.example.com ./tests/db.app-x.gslb.example.com.yml
+				healthcheck_source_address eth0
+			}`,
+			expectError: true,
+		},
+		{
+			name: "Invalid healthcheck so_mark",
+			config: `gslb {
+				zone app-x.gslb.example.com ./tests/db.app-x.gslb.example.com.yml
+				healthcheck_so_mark -1
+			}`,
+			expectError: true,
+		},
+		// Test with the resolution of backend hostnames
+		{
+			name: "Hostname backends resolution",
+			config: `gslb {
+				zone app-x.gslb.example.com ./tests/db.app-x.gslb.example.com.yml
+				hostname_resolver 127.0.0.1:53
+				hostname_refresh_min 10s
+				hostname_refresh_max 10m
+			}`,
+			expectError: false,
+		},
+		{
+			name: "Invalid hostname refresh bounds",
+			config: `gslb {
+				zone app-x.gslb.example.com ./tests/db.app-x.gslb.example.com.yml
+				hostname_refresh_min 10m
+				hostname_refresh_max 1m
+			}`,
+			expectError: true,
+		},
+		// Test with a state file
+		{
+			name: "State file",
+			config: `gslb {
+				zone app-x.gslb.example.com ./tests/db.app-x.gslb.example.com.yml
+				state_file /tmp/gslb-setup-test-state.json
+				state_save_interval 1m
 			}`,
 			expectError: false,
 		},
@@ -99,7 +181,12 @@ func TestSetupGSLB(t *testing.T) {
 			c := caddy.NewTestController("dns", test.config)
 			err := setup(c)
 
-			// Only expect no error for all cases
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("Expected an error for test: %v", test.name)
+				}
+				return
+			}
 			if err != nil {
 				t.Fatalf("Expected no error, but got: %v for test: %v", err, test.name)
 			}
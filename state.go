@@ -0,0 +1,180 @@
+package gslb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// backendState is the persisted health of a backend.
+type backendState struct {
+	Alive           bool      `json:"alive"`
+	Degraded        bool      `json:"degraded"`
+	Enable          bool      `json:"enable"`
+	LastHealthcheck time.Time `json:"last_healthcheck"`
+}
+
+// gslbState is the content of the state file.
+type gslbState struct {
+	SavedAt time.Time                          `json:"saved_at"`
+	Records map[string]map[string]backendState `json:"records"` // fqdn -> address -> state
+}
+
+// snapshotState returns the current health of all backends.
+func (g *GSLB) snapshotState() *gslbState {
+	state := &gslbState{SavedAt: time.Now(), Records: make(map[string]map[string]backendState)}
+	g.Mutex.RLock()
+	defer g.Mutex.RUnlock()
+	for _, records := range g.Records {
+		for fqdn, record := range records {
+			record.mutex.RLock()
+			backends := make(map[string]backendState)
+			for _, be := range record.Backends {
+				b, ok := be.(*Backend)
+				if !ok {
+					continue
+				}
+				b.mutex.RLock()
+				// Stale backends are saved with the state they were restored from
+				backends[b.Address] = backendState{Alive: b.Alive, Degraded: b.Degraded, Enable: b.Enable, LastHealthcheck: b.LastHealthcheck}
+				b.mutex.RUnlock()
+			}
+			record.mutex.RUnlock()
+			state.Records[fqdn] = backends
+		}
+	}
+	return state
+}
+
+// saveState writes the current health of all backends to the state file, atomically.
+func (g *GSLB) saveState() error {
+	data, err := json.Marshal(g.snapshotState())
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(g.StateFile), filepath.Base(g.StateFile)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return os.Rename(tmp.Name(), g.StateFile)
+}
+
+// loadState reads a state file, a missing file returns a nil state.
+func loadState(path string) (*gslbState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state gslbState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+// restoreState applies the last known health to the loaded backends, marked
+// as stale until their first health check. Enable flags are only restored
+// for zones whose file was not modified after the state was saved, so that
+// edits made while stopped win. The backends of hostnames not resolved yet
+// get their state on the first resolution.
+func (g *GSLB) restoreState(state *gslbState) int {
+	restored := 0
+	for zone, records := range g.Records {
+		restoreEnable := false
+		if info, err := os.Stat(g.Zones[zone]); err == nil {
+			restoreEnable = !info.ModTime().After(state.SavedAt)
+		}
+		for fqdn, record := range records {
+			backends, found := state.Records[fqdn]
+			if !found {
+				continue
+			}
+			record.mutex.Lock()
+			for _, be := range record.Backends {
+				b, ok := be.(*Backend)
+				if !ok {
+					continue
+				}
+				saved, found := backends[b.Address]
+				if !found {
+					continue
+				}
+				b.restoreState(saved, restoreEnable)
+				restored++
+			}
+			for _, h := range record.hostnames {
+				if h.addresses == nil {
+					h.saved, h.restoreEnable = backends, restoreEnable
+				}
+			}
+			record.mutex.Unlock()
+		}
+	}
+	return restored
+}
+
+// restoreState applies a saved state to the backend, marked as stale.
+func (b *Backend) restoreState(saved backendState, restoreEnable bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.Alive = saved.Alive
+	b.Degraded = saved.Degraded
+	b.LastHealthcheck = saved.LastHealthcheck
+	if restoreEnable {
+		b.Enable = saved.Enable
+	}
+	b.Stale = true
+}
+
+// loadStateFile restores the state file if configured, errors are logged only.
+func (g *GSLB) loadStateFile() {
+	if g.StateFile == "" {
+		return
+	}
+	state, err := loadState(g.StateFile)
+	if err != nil {
+		log.Errorf("Failed to load state file: %v", err)
+		return
+	}
+	if state == nil {
+		return
+	}
+	restored := g.restoreState(state)
+	log.Infof("Restored the state of %d backends from %s (saved at %s)", restored, g.StateFile, state.SavedAt.Format(time.RFC3339))
+}
+
+// runStateSaver writes the state file periodically until the context is done.
+func (g *GSLB) runStateSaver(ctx context.Context) {
+	ticker := time.NewTicker(g.GetStateSaveInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := g.saveState(); err != nil {
+				log.Errorf("Failed to save state file: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// GetStateSaveInterval returns the interval between two writes of the state file.
+func (g *GSLB) GetStateSaveInterval() time.Duration {
+	return parseDurationWithDefault(g.StateSaveInterval, "30s")
+}
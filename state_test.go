@@ -0,0 +1,129 @@
+package gslb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// newStateTestGSLB returns a GSLB with two backends loaded from the given zone file.
+func newStateTestGSLB(zoneFile, stateFile string) (*GSLB, *Backend, *Backend) {
+	primary := &Backend{Address: "10.0.0.1", Priority: 1, Enable: true}
+	secondary := &Backend{Address: "10.0.0.2", Priority: 2, Enable: true}
+	g := &GSLB{
+		Zones:     map[string]string{"example.com.": zoneFile},
+		StateFile: stateFile,
+		Records: map[string]map[string]*Record{
+			"example.com.": {
+				"app.example.com.": {Fqdn: "app.example.com.", Backends: []BackendInterface{primary, secondary}},
+			},
+		},
+	}
+	return g, primary, secondary
+}
+
+func TestGSLB_SaveAndRestoreState(t *testing.T) {
+	dir := t.TempDir()
+	zoneFile := filepath.Join(dir, "db.example.com.yml")
+	stateFile := filepath.Join(dir, "state.json")
+	assert.NoError(t, os.WriteFile(zoneFile, []byte("records: {}\n"), 0644))
+	past := time.Now().Add(-time.Hour)
+	assert.NoError(t, os.Chtimes(zoneFile, past, past))
+
+	g, primary, secondary := newStateTestGSLB(zoneFile, stateFile)
+	primary.Alive = true
+	primary.Degraded = true
+	secondary.Enable = false
+	assert.NoError(t, g.saveState())
+
+	// After a restart, the backends get their last known health back, marked as stale
+	restarted, primary, secondary := newStateTestGSLB(zoneFile, stateFile)
+	restarted.loadStateFile()
+	assert.True(t, primary.IsHealthy())
+	assert.True(t, primary.Degraded)
+	assert.True(t, primary.Stale)
+	assert.False(t, secondary.IsHealthy())
+	assert.False(t, secondary.Enable)
+	assert.True(t, secondary.Stale)
+
+	// The first health check confirms the state
+	primary.HealthChecks = []GenericHealthCheck{&MockHealthCheck{}}
+	primary.runHealthChecks(scrapeSettings{timeout: time.Second})
+	assert.False(t, primary.Stale)
+	assert.False(t, primary.Degraded)
+}
+
+func TestGSLB_RestoreState_ZoneFileModified(t *testing.T) {
+	dir := t.TempDir()
+	zoneFile := filepath.Join(dir, "db.example.com.yml")
+	stateFile := filepath.Join(dir, "state.json")
+
+	g, primary, secondary := newStateTestGSLB(zoneFile, stateFile)
+	primary.Alive = true
+	secondary.Enable = false
+	assert.NoError(t, g.saveState())
+
+	// The zone file was edited after the state was saved, its enable flags win
+	assert.NoError(t, os.WriteFile(zoneFile, []byte("records: {}\n"), 0644))
+	future := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(zoneFile, future, future))
+
+	restarted, primary, secondary := newStateTestGSLB(zoneFile, stateFile)
+	restarted.loadStateFile()
+	assert.True(t, primary.Alive)
+	assert.True(t, secondary.Enable)
+}
+
+func TestGSLB_RestoreState_HostnameBackends(t *testing.T) {
+	dir := t.TempDir()
+	zoneFile := filepath.Join(dir, "db.example.com.yml")
+	assert.NoError(t, os.WriteFile(zoneFile, []byte("records: {}\n"), 0644))
+	past := time.Now().Add(-time.Hour)
+	assert.NoError(t, os.Chtimes(zoneFile, past, past))
+
+	var record Record
+	require.NoError(t, yaml.Unmarshal([]byte(`
+backends:
+  - address: lb.example.com
+`), &record))
+	record.Fqdn = "app.example.com."
+	g := &GSLB{
+		Zones:   map[string]string{"example.com.": zoneFile},
+		Records: map[string]map[string]*Record{"example.com.": {"app.example.com.": &record}},
+	}
+	state := &gslbState{SavedAt: time.Now(), Records: map[string]map[string]backendState{
+		"app.example.com.": {"192.0.2.10": {Alive: true, Degraded: true}},
+	}}
+	assert.Equal(t, 0, g.restoreState(state))
+
+	// The backends of the first resolution get their saved state
+	record.mutex.Lock()
+	record.applyHostnameAddresses(record.hostnames[0], []string{"192.0.2.10", "192.0.2.11"})
+	record.mutex.Unlock()
+	require.Len(t, record.Backends, 2)
+	restored, added := record.Backends[0].(*Backend), record.Backends[1].(*Backend)
+	assert.True(t, restored.Alive)
+	assert.True(t, restored.Degraded)
+	assert.True(t, restored.Stale)
+	assert.False(t, added.Alive)
+	assert.False(t, added.Stale)
+	assert.Nil(t, record.hostnames[0].saved)
+}
+
+func TestLoadState(t *testing.T) {
+	dir := t.TempDir()
+
+	state, err := loadState(filepath.Join(dir, "missing.json"))
+	assert.NoError(t, err)
+	assert.Nil(t, state)
+
+	invalid := filepath.Join(dir, "invalid.json")
+	assert.NoError(t, os.WriteFile(invalid, []byte("{"), 0644))
+	_, err = loadState(invalid)
+	assert.Error(t, err)
+}